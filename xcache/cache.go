@@ -1,6 +1,7 @@
 package xcache
 
 import (
+	"context"
 	"runtime"
 	"sync"
 	"time"
@@ -13,55 +14,145 @@ const (
 	DefaultExpiration time.Duration = 0
 )
 
-// Cache construct to control garbage collector
-type Cache struct {
-	*cache
+// TypedCache construct to control garbage collector
+type TypedCache[K comparable, V any] struct {
+	*cache[K, V]
 }
 
-type cache struct {
+type cache[K comparable, V any] struct {
 	expiration time.Duration
-	items      map[string]Item
+	items      map[K]entry[V]
 	mu         sync.RWMutex
-	onEvicted  func(string, interface{})
+	onEvicted  func(K, V, EvictionReason)
 	janitor    *janitor
+	maxEntries int
+	maxCost    int64
+	cost       int64
+	policy     evictionPolicy[K]
+	// negative caches loader errors for negativeTTL, guarded by mu
+	negativeTTL time.Duration
+	negatives   map[K]negative
+	// in-flight loads per key, guarded by loadMu
+	loadMu sync.Mutex
+	calls  map[K]*call[V]
+	// loader refreshes stale items in the background, guarded by mu
+	loader       func(context.Context, K) (V, time.Duration, error)
+	staleTTL     time.Duration
+	refreshAhead float64
+	stats        stats
+	// ctx bounds the janitor and background loads, cancelled by Close
+	ctx    context.Context
+	cancel context.CancelFunc
+	loads  sync.WaitGroup
+	// secondary indexes for invalidation, guarded by mu. The prefix index is
+	// only built once DeletePrefix is first used
+	tags     map[string]map[K]struct{}
+	prefixes *trie[K]
 }
 
-// Item is a generic interface holding the cache object
-type Item struct {
-	Object     interface{}
+// TypedItem is a generic interface holding the cache object
+type TypedItem[V any] struct {
+	Object     V
 	Expiration int64
 }
 
-// New returns a cache with a given default expiration and cleanup
-// interval. If the expiration duration is less than one (or NoExpiration),
-// the items in the cache never expire (by default)
-func New(expiration, cleanupInterval time.Duration) *Cache {
+// Cache is the untyped cache keyed by string returned by New
+type Cache = TypedCache[string, interface{}]
 
-	if expiration == 0 {
-		expiration = -1
-	}
+// Item is the item of an untyped cache
+type Item = TypedItem[interface{}]
 
-	c := &cache{
-		expiration: expiration,
-		items:      make(map[string]Item),
-	}
+// entry is the stored form of an Item including its bookkeeping
+type entry[V any] struct {
+	TypedItem[V]
+	cost int64
+	ttl  time.Duration
+	tags []string
+}
+
+type eviction[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictionReason
+}
+
+// New returns an untyped cache keyed by string with a given default expiration
+// and cleanup interval. If the expiration duration is less than one (or NoExpiration),
+// the items in the cache never expire (by default)
+func New(expiration, cleanupInterval time.Duration, opts ...Option) *Cache {
+	return NewCache[string, interface{}](expiration, cleanupInterval, opts...)
+}
+
+// NewCache returns a type-safe cache with a given default expiration and cleanup
+// interval. See New for the meaning of the durations
+func NewCache[K comparable, V any](expiration, cleanupInterval time.Duration, opts ...Option) *TypedCache[K, V] {
+	c := newCache[K, V](expiration, newOptions(opts))
 	// trick ensures that the janitor routine does not keep
 	// C from being garbage collected.
 	// On garbage collection, the finalizer stops the janitor routine,
 	// and c will be collected.
-	C := &Cache{c}
+	C := &TypedCache[K, V]{c}
 	if cleanupInterval > 0 {
-		runJanitor(c, cleanupInterval)
-		runtime.SetFinalizer(C, stopJanitor)
+		c.janitor = runJanitor(c.ctx, c.deleteExpired, cleanupInterval)
+		runtime.SetFinalizer(C, stopJanitor[K, V])
 	}
 
 	return C
 }
 
+func newCache[K comparable, V any](expiration time.Duration, o *options) *cache[K, V] {
+
+	if expiration == 0 {
+		expiration = -1
+	}
+
+	c := &cache[K, V]{
+		expiration:   expiration,
+		items:        make(map[K]entry[V]),
+		maxEntries:   o.maxEntries,
+		maxCost:      o.maxCost,
+		negativeTTL:  o.negativeTTL,
+		staleTTL:     o.staleTTL,
+		refreshAhead: o.refreshAhead,
+		stats:        stats{hook: o.statsHook},
+	}
+	if o.bounded() {
+		c.policy = newPolicy[K](o.policy)
+	}
+	c.ctx, c.cancel = context.WithCancel(o.ctx)
+
+	return c
+}
+
+// Close stops the janitor and any background load or refresh and waits for them to
+// exit. The cache remains usable, but expired items are no longer cleaned up
+// automatically. Close is safe to call more than once
+func (c *cache[K, V]) Close() {
+	if c.janitor != nil {
+		c.janitor.halt()
+	}
+	// no load can be started once the context is cancelled under loadMu
+	c.loadMu.Lock()
+	c.cancel()
+	c.loadMu.Unlock()
+	c.loads.Wait()
+}
+
 // Set an item to the cache, replacing any existing item. If the duration is 0
 // (DefaultExpiration), the cache's default expiration time is used. If it is -1
 // (NoExpiration), the item never expires.
-func (c *cache) Set(k string, x interface{}, d time.Duration) {
+func (c *cache[K, V]) Set(k K, x V, d time.Duration) {
+	c.SetWithCost(k, x, d, 1)
+}
+
+// SetWithCost adds an item like Set, weighing it with cost against the
+// limit configured by WithMaxCost. The cost is ignored by unbounded caches
+func (c *cache[K, V]) SetWithCost(k K, x V, d time.Duration, cost int64) {
+	_ = c.store(k, c.newEntry(x, d, cost, nil), nil)
+}
+
+// newEntry resolves the expiration of a new item
+func (c *cache[K, V]) newEntry(x V, d time.Duration, cost int64, tags []string) entry[V] {
 	var e int64
 	if d == DefaultExpiration {
 		d = c.expiration
@@ -69,38 +160,141 @@ func (c *cache) Set(k string, x interface{}, d time.Duration) {
 	if d > 0 {
 		e = time.Now().Add(d).UnixNano()
 	}
+	return entry[V]{
+		TypedItem: TypedItem[V]{
+			Object:     x,
+			Expiration: e,
+		},
+		cost: cost,
+		ttl:  d,
+		tags: tags,
+	}
+}
+
+// store sets the entry unless the optional check on whether k currently holds
+// an unexpired item fails
+func (c *cache[K, V]) store(k K, e entry[V], check func(found bool) error) error {
 	c.mu.Lock()
-	c.items[k] = Item{
-		Object:     x,
-		Expiration: e,
+	if check != nil {
+		_, found := c.lookup(k)
+		if err := check(found); err != nil {
+			c.mu.Unlock()
+			return err
+		}
 	}
+	c.set(k, e)
+	evicted := c.evict()
 	c.mu.Unlock()
+	c.notify(evicted)
+	return nil
+}
+
+// set stores the entry and updates the bookkeeping, the caller must hold the write lock
+func (c *cache[K, V]) set(k K, e entry[V]) {
+	old, exists := c.items[k]
+	c.stats.set()
+	delete(c.negatives, k)
+	c.items[k] = e
+	c.cost += e.cost - old.cost
+	if len(old.tags) > 0 || len(e.tags) > 0 {
+		c.untag(k, old.tags)
+		c.tag(k, e.tags)
+	}
+	if !exists && c.prefixes != nil {
+		c.prefixes.insert(keyString(k), k)
+	}
+	if c.policy == nil {
+		return
+	}
+	if exists {
+		c.policy.touch(k)
+	} else {
+		c.policy.add(k)
+	}
+}
+
+// evict removes victims until the cache respects its bounds again.
+// The caller must hold the write lock and notify the returned evictions once released
+func (c *cache[K, V]) evict() []eviction[K, V] {
+	if c.policy == nil {
+		return nil
+	}
+	var evicted []eviction[K, V]
+	for c.overCapacity() {
+		k, ok := c.policy.victim()
+		if !ok {
+			break
+		}
+		v, found := c.delete(k, Capacity)
+		if found {
+			evicted = append(evicted, eviction[K, V]{key: k, value: v, reason: Capacity})
+		}
+	}
+	return evicted
+}
+
+func (c *cache[K, V]) overCapacity() bool {
+	return (c.maxEntries > 0 && len(c.items) > c.maxEntries) ||
+		(c.maxCost > 0 && c.cost > c.maxCost)
 }
 
-// Get an item from the cache or nil, and a bool indicating
-// whether the key was found.
-func (c *cache) Get(k string) (interface{}, bool) {
+func (c *cache[K, V]) notify(evicted []eviction[K, V]) {
+	if len(evicted) == 0 {
+		return
+	}
+	c.mu.RLock()
+	onEvicted := c.onEvicted
+	c.mu.RUnlock()
+	if onEvicted == nil {
+		return
+	}
+	for _, e := range evicted {
+		onEvicted(e.key, e.value, e.reason)
+	}
+}
+
+// Get an item from the cache or the zero value, and a bool indicating
+// whether the key was found. With a loader registered through SetLoader, an
+// item within its stale window is still returned while it is refreshed in the background
+func (c *cache[K, V]) Get(k K) (V, bool) {
+	var zero V
 	c.mu.RLock()
 	item, exists := c.items[k]
 	if !exists {
 		c.mu.RUnlock()
-		return nil, false
+		c.stats.miss()
+		return zero, false
 	}
+	var refresh bool
 	if item.Expiration > 0 {
-		if time.Now().UnixNano() > item.Expiration {
-			c.mu.RUnlock()
-			return nil, false
+		now := time.Now().UnixNano()
+		if now > item.Expiration {
+			if c.loader == nil || now > item.Expiration+int64(c.staleTTL) {
+				c.mu.RUnlock()
+				c.stats.miss()
+				return zero, false
+			}
+			refresh = true
+		} else if c.loader != nil && c.refreshAhead > 0 && item.ttl > 0 {
+			refresh = float64(item.Expiration-now) < c.refreshAhead*float64(item.ttl)
 		}
 	}
+	if c.policy != nil {
+		c.policy.touch(k)
+	}
 	c.mu.RUnlock()
+	c.stats.hit()
+	if refresh {
+		c.refresh(k)
+	}
 	return item.Object, true
 }
 
 // Copies all unexpired items in the cache into a new map and returns it.
-func (c *cache) Items() map[string]Item {
+func (c *cache[K, V]) Items() map[K]TypedItem[V] {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	m := make(map[string]Item, len(c.items))
+	m := make(map[K]TypedItem[V], len(c.items))
 	now := time.Now().UnixNano()
 	for k, v := range c.items {
 		// "Inlining" of Expired
@@ -109,46 +303,93 @@ func (c *cache) Items() map[string]Item {
 				continue
 			}
 		}
-		m[k] = v
+		m[k] = v.TypedItem
 	}
 	return m
 }
 
+// ItemCount returns the number of items in the cache, including expired
+// items which have not yet been cleaned up.
+func (c *cache[K, V]) ItemCount() int {
+	c.mu.RLock()
+	n := len(c.items)
+	c.mu.RUnlock()
+	return n
+}
+
 // delete all expired items from the cache.
-func (c *cache) deleteExpired() {
+func (c *cache[K, V]) deleteExpired() {
+	var evicted []eviction[K, V]
 	now := time.Now().UnixNano()
+	c.mu.Lock()
 	for k, v := range c.items {
-		if v.Expiration > 0 && now > v.Expiration {
-			c.Delete(k)
+		// stale items are kept around until their stale window passed
+		if v.Expiration > 0 && now > v.Expiration+int64(c.staleTTL) {
+			if x, found := c.delete(k, Expired); found {
+				evicted = append(evicted, eviction[K, V]{key: k, value: x, reason: Expired})
+			}
+		}
+	}
+	for k, n := range c.negatives {
+		if now > n.expiration {
+			delete(c.negatives, k)
 		}
 	}
+	c.mu.Unlock()
+	c.notify(evicted)
 }
 
 // Delete an item from the cache. Does nothing if the key is not in the cache.
-func (c *cache) Delete(k string) {
+func (c *cache[K, V]) Delete(k K) {
+	c.deleteWithReason(k, Deleted)
+}
+
+func (c *cache[K, V]) deleteWithReason(k K, reason EvictionReason) {
 	c.mu.Lock()
-	v, evicted := c.delete(k)
+	v, found := c.delete(k, reason)
+	onEvicted := c.onEvicted
 	c.mu.Unlock()
-	if evicted {
-		c.onEvicted(k, v)
+	if found && onEvicted != nil {
+		onEvicted(k, v, reason)
 	}
 }
 
-func (c *cache) delete(k string) (interface{}, bool) {
-	if c.onEvicted != nil {
-		if v, found := c.items[k]; found {
-			delete(c.items, k)
-			return v.Object, true
-		}
+func (c *cache[K, V]) delete(k K, reason EvictionReason) (V, bool) {
+	var zero V
+	delete(c.negatives, k)
+	v, found := c.items[k]
+	if !found {
+		return zero, false
 	}
 	delete(c.items, k)
-	return nil, false
+	c.cost -= v.cost
+	c.untag(k, v.tags)
+	if c.prefixes != nil {
+		c.prefixes.remove(keyString(k))
+	}
+	if c.policy != nil {
+		c.policy.remove(k)
+	}
+	c.stats.evicted(reason)
+	return v.Object, true
 }
 
 // Sets an (optional) function that is called with the key and value when an
 // item is evicted from the cache. (Including when it is deleted manually, but
 // not when it is overwritten.) Set to nil to disable.
-func (c *cache) OnEvicted(f func(string, interface{})) {
+func (c *cache[K, V]) OnEvicted(f func(K, V)) {
+	if f == nil {
+		c.OnEvictedWithReason(nil)
+		return
+	}
+	c.OnEvictedWithReason(func(k K, v V, _ EvictionReason) {
+		f(k, v)
+	})
+}
+
+// OnEvictedWithReason is like OnEvicted but additionally passes why the item
+// was evicted. Set to nil to disable.
+func (c *cache[K, V]) OnEvictedWithReason(f func(K, V, EvictionReason)) {
 	c.mu.Lock()
 	c.onEvicted = f
 	c.mu.Unlock()
@@ -66,6 +66,27 @@ func TestCache(t *testing.T) {
 	}
 }
 
+// untyped holds the cache the way callers did before the cache was generic
+type untyped struct {
+	cache *Cache
+}
+
+func TestUntypedAliases(t *testing.T) {
+	u := untyped{cache: New(DefaultExpiration, 0)}
+	u.cache.Set("a", 1, NoExpiration)
+
+	var items map[string]Item = u.cache.Items()
+	var item Item = items["a"]
+	if item.Object != 1 || item.Expiration != 0 {
+		t.Error("unexpected item:", item)
+	}
+
+	var typed *TypedCache[string, interface{}] = u.cache
+	if x, found := typed.Get("a"); !found || x != 1 {
+		t.Error("expected Cache to be the untyped TypedCache, got:", x, found)
+	}
+}
+
 func TestDelete(t *testing.T) {
 	tc := New(DefaultExpiration, 0)
 	tc.Set("foo", "bar", DefaultExpiration)
@@ -160,6 +181,37 @@ func TestStorePointerToStruct(t *testing.T) {
 	}
 }
 
+func TestTypedCache(t *testing.T) {
+	tc := NewCache[int, *TestStruct](DefaultExpiration, 0)
+
+	x, found := tc.Get(1)
+	if found || x != nil {
+		t.Error("Getting 1 found value that shouldn't exist:", x)
+	}
+
+	tc.Set(1, &TestStruct{Num: 1}, DefaultExpiration)
+	x, found = tc.Get(1)
+	if !found {
+		t.Fatal("*TestStruct was not found for 1")
+	}
+	if x.Num != 1 {
+		t.Error("TestStruct.Num is not 1; value:", x.Num)
+	}
+
+	var evicted int
+	tc.OnEvicted(func(k int, v *TestStruct) {
+		evicted = k + v.Num
+	})
+	tc.Delete(1)
+	if evicted != 2 {
+		t.Error("onEvicted was not called with the typed key and value; got:", evicted)
+	}
+
+	if items := tc.Items(); len(items) != 0 {
+		t.Error("Items is not empty after delete:", items)
+	}
+}
+
 func BenchmarkCacheGetExpiring(b *testing.B) {
 	benchmarkCacheGet(b, 5*time.Minute)
 }
@@ -369,11 +421,9 @@ func BenchmarkRWMutexMapSetDeleteSingleLock(b *testing.B) {
 func BenchmarkDeleteExpiredLoop(b *testing.B) {
 	b.StopTimer()
 	tc := New(5*time.Minute, 0)
-	tc.mu.Lock()
 	for i := 0; i < 100000; i++ {
 		tc.Set(strconv.Itoa(i), "bar", DefaultExpiration)
 	}
-	tc.mu.Unlock()
 	b.StartTimer()
 	for i := 0; i < b.N; i++ {
 		tc.deleteExpired()
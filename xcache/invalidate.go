@@ -0,0 +1,197 @@
+package xcache
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// SetWithTags adds an item like Set and attaches tags to it, so it can be
+// removed together with all other items sharing a tag through InvalidateTag.
+// Overwriting the item replaces its tags
+func (c *cache[K, V]) SetWithTags(k K, x V, d time.Duration, tags ...string) {
+	_ = c.store(k, c.newEntry(x, d, 1, tags), nil)
+}
+
+// InvalidateTag removes all items tagged with tag and returns how many were
+// removed. The onEvicted callback receives the Invalidated reason
+func (c *cache[K, V]) InvalidateTag(tag string) int {
+	c.mu.Lock()
+	keys := make([]K, 0, len(c.tags[tag]))
+	for k := range c.tags[tag] {
+		keys = append(keys, k)
+	}
+	evicted := c.deleteKeys(keys, Invalidated)
+	c.mu.Unlock()
+	c.notify(evicted)
+	return len(evicted)
+}
+
+// DeletePrefix removes all items whose key starts with prefix and returns how
+// many were removed. Non string keys are matched on their fmt.Sprint form. An
+// index is built on first use, after which removals no longer scan the cache
+func (c *cache[K, V]) DeletePrefix(prefix string) int {
+	c.mu.Lock()
+	if c.prefixes == nil {
+		c.prefixes = newTrie[K]()
+		for k := range c.items {
+			c.prefixes.insert(keyString(k), k)
+		}
+	}
+	evicted := c.deleteKeys(c.prefixes.collect(prefix), Deleted)
+	c.mu.Unlock()
+	c.notify(evicted)
+	return len(evicted)
+}
+
+// deleteKeys removes keys, the caller must hold the write lock and notify
+// the returned evictions once released
+func (c *cache[K, V]) deleteKeys(keys []K, reason EvictionReason) []eviction[K, V] {
+	evicted := make([]eviction[K, V], 0, len(keys))
+	for _, k := range keys {
+		if v, found := c.delete(k, reason); found {
+			evicted = append(evicted, eviction[K, V]{key: k, value: v, reason: reason})
+		}
+	}
+	return evicted
+}
+
+// tag adds k to the index of tags, the caller must hold the write lock
+func (c *cache[K, V]) tag(k K, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	if c.tags == nil {
+		c.tags = make(map[string]map[K]struct{})
+	}
+	for _, t := range tags {
+		if c.tags[t] == nil {
+			c.tags[t] = make(map[K]struct{})
+		}
+		c.tags[t][k] = struct{}{}
+	}
+}
+
+// untag removes k from the index of tags, the caller must hold the write lock
+func (c *cache[K, V]) untag(k K, tags []string) {
+	for _, t := range tags {
+		delete(c.tags[t], k)
+		if len(c.tags[t]) == 0 {
+			delete(c.tags, t)
+		}
+	}
+}
+
+// SetWithTags adds an item with tags, see Cache.SetWithTags
+func (sc *shardedCache[K, V]) SetWithTags(k K, x V, d time.Duration, tags ...string) {
+	sc.shard(k).SetWithTags(k, x, d, tags...)
+}
+
+// InvalidateTag removes all items tagged with tag from all segments, see Cache.InvalidateTag
+func (sc *shardedCache[K, V]) InvalidateTag(tag string) int {
+	var n int
+	for _, c := range sc.shards {
+		n += c.InvalidateTag(tag)
+	}
+	return n
+}
+
+// DeletePrefix removes all items whose key starts with prefix from all segments, see Cache.DeletePrefix
+func (sc *shardedCache[K, V]) DeletePrefix(prefix string) int {
+	var n int
+	for _, c := range sc.shards {
+		n += c.DeletePrefix(prefix)
+	}
+	return n
+}
+
+func keyString[K comparable](k K) string {
+	if s, ok := any(k).(string); ok {
+		return s
+	}
+	if v := reflect.ValueOf(k); v.Kind() == reflect.String {
+		return v.String()
+	}
+	return fmt.Sprint(k)
+}
+
+// trie indexes keys by their string form for prefix lookups
+type trie[K comparable] struct {
+	root *trieNode[K]
+}
+
+type trieNode[K comparable] struct {
+	children map[byte]*trieNode[K]
+	key      K
+	leaf     bool
+}
+
+func newTrie[K comparable]() *trie[K] {
+	return &trie[K]{root: new(trieNode[K])}
+}
+
+func (t *trie[K]) insert(s string, k K) {
+	n := t.root
+	for i := 0; i < len(s); i++ {
+		child, ok := n.children[s[i]]
+		if !ok {
+			if n.children == nil {
+				n.children = make(map[byte]*trieNode[K])
+			}
+			child = new(trieNode[K])
+			n.children[s[i]] = child
+		}
+		n = child
+	}
+	n.key = k
+	n.leaf = true
+}
+
+// remove unmarks s and prunes the branches left empty
+func (t *trie[K]) remove(s string) {
+	path := make([]*trieNode[K], 0, len(s)+1)
+	n := t.root
+	path = append(path, n)
+	for i := 0; i < len(s); i++ {
+		child, ok := n.children[s[i]]
+		if !ok {
+			return
+		}
+		n = child
+		path = append(path, n)
+	}
+	var zero K
+	n.key = zero
+	n.leaf = false
+	for i := len(s); i > 0; i-- {
+		if path[i].leaf || len(path[i].children) > 0 {
+			return
+		}
+		delete(path[i-1].children, s[i-1])
+	}
+}
+
+// collect returns all keys starting with prefix
+func (t *trie[K]) collect(prefix string) []K {
+	n := t.root
+	for i := 0; i < len(prefix); i++ {
+		child, ok := n.children[prefix[i]]
+		if !ok {
+			return nil
+		}
+		n = child
+	}
+	var keys []K
+	stack := []*trieNode[K]{n}
+	for len(stack) > 0 {
+		n = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n.leaf {
+			keys = append(keys, n.key)
+		}
+		for _, child := range n.children {
+			stack = append(stack, child)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,92 @@
+package xcache
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestInvalidateTag(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0)
+	var reasons []EvictionReason
+	tc.OnEvictedWithReason(func(_ string, _ int, reason EvictionReason) {
+		reasons = append(reasons, reason)
+	})
+
+	tc.SetWithTags("user:1", 1, DefaultExpiration, "user:1")
+	tc.SetWithTags("user:1:posts", 2, DefaultExpiration, "user:1", "posts")
+	tc.SetWithTags("user:2:posts", 3, DefaultExpiration, "user:2", "posts")
+	tc.Set("other", 4, DefaultExpiration)
+
+	if n := tc.InvalidateTag("user:1"); n != 2 {
+		t.Error("expected 2 items to be invalidated, got:", n)
+	}
+	if _, found := tc.Get("user:1:posts"); found {
+		t.Error("user:1:posts should have been invalidated")
+	}
+	if _, found := tc.Get("user:2:posts"); !found {
+		t.Error("user:2:posts should not have been invalidated")
+	}
+	for _, reason := range reasons {
+		if reason != Invalidated {
+			t.Error("expected reason invalidated, got:", reason)
+		}
+	}
+
+	// overwriting replaces the tags of an item
+	tc.Set("user:2:posts", 3, DefaultExpiration)
+	if n := tc.InvalidateTag("posts"); n != 0 {
+		t.Error("expected the posts tag to be dropped on overwrite, got:", n)
+	}
+	if len(tc.tags) != 0 {
+		t.Error("expected the tag index to be empty, got:", tc.tags)
+	}
+	if n := tc.InvalidateTag("unknown"); n != 0 {
+		t.Error("expected nothing to be invalidated, got:", n)
+	}
+}
+
+func TestDeletePrefix(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0)
+	tc.Set("user:1", 1, DefaultExpiration)
+	tc.Set("user:1:posts", 2, DefaultExpiration)
+	tc.Set("user:10", 3, DefaultExpiration)
+	tc.Set("user:2", 4, DefaultExpiration)
+
+	if n := tc.DeletePrefix("user:1"); n != 3 {
+		t.Error("expected 3 items to be deleted, got:", n)
+	}
+
+	// the index is maintained after first use
+	tc.Set("user:11", 5, DefaultExpiration)
+	tc.Set("user:3", 6, DefaultExpiration)
+	tc.Delete("user:3")
+	if n := tc.DeletePrefix("user:1"); n != 1 {
+		t.Error("expected user:11 to be deleted, got:", n)
+	}
+	if n := tc.DeletePrefix("user:3"); n != 0 {
+		t.Error("expected no deleted key to be left in the index, got:", n)
+	}
+
+	var keys []string
+	for k := range tc.Items() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) != 1 || keys[0] != "user:2" {
+		t.Error("expected only user:2 to be left, got:", keys)
+	}
+}
+
+func TestDeletePrefixSharded(t *testing.T) {
+	tc := NewSharded[int, int](4, DefaultExpiration, 0)
+	for i := 0; i < 30; i++ {
+		tc.SetWithTags(i, i, DefaultExpiration, "all")
+	}
+	// 1 and 10 to 19
+	if n := tc.DeletePrefix("1"); n != 11 {
+		t.Error("expected 11 items to be deleted, got:", n)
+	}
+	if n := tc.InvalidateTag("all"); n != 19 {
+		t.Error("expected the remaining 19 items to be invalidated, got:", n)
+	}
+}
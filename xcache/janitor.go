@@ -1,34 +1,53 @@
 package xcache
 
-import "time"
+import (
+	"context"
+	"sync"
+	"time"
+)
 
 // clean up loop
 type janitor struct {
 	interval time.Duration
-	stop     chan bool
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
 }
 
-func (j *janitor) run(c *cache) {
+func (j *janitor) run(ctx context.Context, sweep func()) {
+	defer close(j.done)
 	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
-			c.deleteExpired()
+			sweep()
 		case <-j.stop:
-			ticker.Stop()
+			return
+		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-func runJanitor(c *cache, ci time.Duration) {
-	c.janitor = &janitor{
+// halt stops the clean up loop and waits for it to exit, it is safe to call more than once
+func (j *janitor) halt() {
+	j.once.Do(func() {
+		close(j.stop)
+	})
+	<-j.done
+}
+
+func runJanitor(ctx context.Context, sweep func(), ci time.Duration) *janitor {
+	j := &janitor{
 		interval: ci,
-		stop:     make(chan bool),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
 	}
-	go c.janitor.run(c)
+	go j.run(ctx, sweep)
+	return j
 }
 
-func stopJanitor(c *Cache) {
-	c.janitor.stop <- true
+func stopJanitor[K comparable, V any](c *TypedCache[K, V]) {
+	c.Close()
 }
@@ -0,0 +1,103 @@
+package xcache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// assertStopped fails when the janitor loop j has not exited
+func assertStopped(t *testing.T, j *janitor) {
+	t.Helper()
+	select {
+	case <-j.done:
+	case <-time.After(time.Second):
+		t.Error("janitor is still running")
+	}
+}
+
+func TestCloseStopsJanitor(t *testing.T) {
+	caches := make([]*TypedCache[string, int], 10)
+	for i := range caches {
+		caches[i] = NewCache[string, int](DefaultExpiration, time.Millisecond)
+		if caches[i].janitor == nil {
+			t.Fatal("expected a janitor per cache")
+		}
+	}
+	sharded := NewSharded[string, int](4, DefaultExpiration, time.Millisecond)
+
+	for _, tc := range caches {
+		tc.Close()
+		// closing twice is a no-op
+		tc.Close()
+		assertStopped(t, tc.janitor)
+	}
+	sharded.Close()
+	assertStopped(t, sharded.janitor)
+}
+
+func TestContextStopsJanitor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tc := NewCache[string, int](DefaultExpiration, time.Millisecond, WithContext(ctx))
+	sharded := NewSharded[string, int](4, DefaultExpiration, time.Millisecond, WithContext(ctx))
+	cancel()
+
+	assertStopped(t, tc.janitor)
+	assertStopped(t, sharded.janitor)
+}
+
+func TestCloseWaitsForRefresh(t *testing.T) {
+	tc := NewCache[string, int](time.Millisecond, 0, WithStaleTTL(time.Minute))
+	var calls atomic.Int32
+	started := make(chan struct{})
+	returned := make(chan struct{})
+	tc.SetLoader(func(ctx context.Context, k string) (int, time.Duration, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+			defer close(returned)
+		}
+		<-ctx.Done()
+		return 0, 0, ctx.Err()
+	})
+	tc.Set("a", 1, DefaultExpiration)
+	<-time.After(2 * time.Millisecond)
+	tc.Get("a")
+	<-started
+
+	tc.Close()
+	select {
+	case <-returned:
+	default:
+		t.Error("Close returned before the refresh")
+	}
+
+	// no refresh is started once closed
+	tc.Get("a")
+	if n := calls.Load(); n != 1 {
+		t.Error("expected a single refresh, got:", n)
+	}
+}
+
+func TestJanitorExpiredReason(t *testing.T) {
+	tc := NewCache[string, int](time.Millisecond, time.Millisecond)
+	defer tc.Close()
+
+	evicted := make(chan EvictionReason, 1)
+	tc.OnEvictedWithReason(func(k string, v int, reason EvictionReason) {
+		evicted <- reason
+	})
+	tc.Set("a", 1, DefaultExpiration)
+
+	select {
+	case reason := <-evicted:
+		if reason != Expired {
+			t.Error("expected reason expired, got:", reason)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expired item was not evicted by the janitor")
+	}
+	if n := tc.ItemCount(); n != 0 {
+		t.Error("expected an empty cache, got:", n)
+	}
+}
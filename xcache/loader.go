@@ -0,0 +1,187 @@
+package xcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// call is an in-flight or completed load shared by all callers of the same key
+type call[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+}
+
+// wait blocks until the load completes or the caller gives up
+func (cl *call[V]) wait(ctx context.Context) (V, error) {
+	select {
+	case <-cl.done:
+		return cl.val, cl.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// negative remembers a failed load so it is not retried before expiration
+type negative struct {
+	err        error
+	expiration int64
+}
+
+// GetOrLoad returns the cached value for k or calls loader to compute it. The
+// duration returned by loader is used as the item expiration like for Set.
+// Concurrent calls for the same key share a single load and all receive its
+// result or error. The loader runs with the values of the first caller's
+// context but detached from its cancellation, so a caller giving up never fails
+// the load for the others; it is only cancelled by Close. Every caller stops
+// waiting when its own context is done. When configured WithNegativeTTL,
+// loader errors other than context errors are cached and returned without
+// calling loader again
+func (c *cache[K, V]) GetOrLoad(ctx context.Context, k K, loader func(ctx context.Context) (V, time.Duration, error)) (V, error) {
+	if v, found := c.Get(k); found {
+		return v, nil
+	}
+	if err, found := c.getNegative(k); found {
+		var zero V
+		return zero, err
+	}
+
+	c.loadMu.Lock()
+	if cl, ok := c.calls[k]; ok {
+		c.loadMu.Unlock()
+		return cl.wait(ctx)
+	}
+	// another load may have completed since the first lookup. Get must not be
+	// used here as it may start a refresh, which takes loadMu as well
+	c.mu.RLock()
+	item, found := c.lookup(k)
+	c.mu.RUnlock()
+	if found {
+		c.loadMu.Unlock()
+		return item.Object, nil
+	}
+	cl := c.newCall(k)
+	if c.ctx.Err() != nil {
+		// a closed cache has no background loads, load inline with the caller
+		c.loadMu.Unlock()
+		c.load(ctx, k, cl, loader)
+		return cl.val, cl.err
+	}
+	c.loads.Add(1)
+	c.loadMu.Unlock()
+
+	lctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(c.ctx, cancel)
+	go func() {
+		defer c.loads.Done()
+		defer cancel()
+		defer stop()
+		c.load(lctx, k, cl, loader)
+	}()
+
+	return cl.wait(ctx)
+}
+
+// newCall registers an in-flight load for k, the caller must hold loadMu
+func (c *cache[K, V]) newCall(k K) *call[V] {
+	cl := &call[V]{done: make(chan struct{})}
+	if c.calls == nil {
+		c.calls = make(map[K]*call[V])
+	}
+	c.calls[k] = cl
+	return cl
+}
+
+func (c *cache[K, V]) load(ctx context.Context, k K, cl *call[V], loader func(ctx context.Context) (V, time.Duration, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			cl.err = fmt.Errorf("xcache: loader for %v panicked: %v", k, r)
+		}
+		c.loadMu.Lock()
+		delete(c.calls, k)
+		c.loadMu.Unlock()
+		close(cl.done)
+	}()
+
+	v, d, err := loader(ctx)
+	if err != nil {
+		cl.err = err
+		// a cancelled load says nothing about the key
+		if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			c.setNegative(k, err)
+		}
+		return
+	}
+
+	cl.val = v
+	c.Set(k, v, d)
+}
+
+func (c *cache[K, V]) getNegative(k K) (error, bool) {
+	if c.negativeTTL <= 0 {
+		return nil, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	n, found := c.negatives[k]
+	if !found || time.Now().UnixNano() > n.expiration {
+		return nil, false
+	}
+	return n.err, true
+}
+
+func (c *cache[K, V]) setNegative(k K, err error) {
+	if c.negativeTTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	if c.negatives == nil {
+		c.negatives = make(map[K]negative)
+	}
+	c.negatives[k] = negative{
+		err:        err,
+		expiration: time.Now().Add(c.negativeTTL).UnixNano(),
+	}
+	c.mu.Unlock()
+}
+
+// SetLoader registers the function used to refresh stale items in the background,
+// see WithStaleTTL and WithRefreshAhead. Set to nil to disable.
+func (c *cache[K, V]) SetLoader(loader func(ctx context.Context, k K) (V, time.Duration, error)) {
+	c.mu.Lock()
+	c.loader = loader
+	c.mu.Unlock()
+}
+
+// refresh reloads k in the background unless a load for it is already in flight
+// or a recent load failed
+func (c *cache[K, V]) refresh(k K) {
+	if _, found := c.getNegative(k); found {
+		return
+	}
+	c.mu.RLock()
+	loader := c.loader
+	c.mu.RUnlock()
+	if loader == nil {
+		return
+	}
+
+	c.loadMu.Lock()
+	if _, ok := c.calls[k]; ok || c.ctx.Err() != nil {
+		c.loadMu.Unlock()
+		return
+	}
+	cl := c.newCall(k)
+	c.loads.Add(1)
+	c.loadMu.Unlock()
+
+	go func() {
+		defer c.loads.Done()
+		c.load(c.ctx, k, cl, func(ctx context.Context) (V, time.Duration, error) {
+			return loader(ctx, k)
+		})
+	}()
+}
@@ -0,0 +1,296 @@
+package xcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0)
+	var calls int32
+	loader := func(ctx context.Context) (int, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		return 42, DefaultExpiration, nil
+	}
+
+	v, err := tc.GetOrLoad(context.Background(), "a", loader)
+	if err != nil || v != 42 {
+		t.Fatal("unexpected load result:", v, err)
+	}
+	v, err = tc.GetOrLoad(context.Background(), "a", loader)
+	if err != nil || v != 42 {
+		t.Fatal("unexpected cached result:", v, err)
+	}
+	if calls != 1 {
+		t.Error("loader should only be called once, got:", calls)
+	}
+}
+
+func TestGetOrLoadDeduplicates(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0)
+	var calls int32
+	release := make(chan struct{})
+	errLoad := errors.New("backend down")
+	loader := func(ctx context.Context) (int, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 0, 0, errLoad
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := tc.GetOrLoad(context.Background(), "a", loader)
+			errs <- err
+		}()
+	}
+	// give all goroutines the chance to join the in-flight load
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	if calls != 1 {
+		t.Error("concurrent loads should be deduplicated, got calls:", calls)
+	}
+	for err := range errs {
+		if !errors.Is(err, errLoad) {
+			t.Error("loader error was not propagated to waiter:", err)
+		}
+	}
+}
+
+func TestGetOrLoadNegativeTTL(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0, WithNegativeTTL(20*time.Millisecond))
+	var calls int32
+	errLoad := errors.New("not found")
+	loader := func(ctx context.Context) (int, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, 0, errLoad
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := tc.GetOrLoad(context.Background(), "a", loader); !errors.Is(err, errLoad) {
+			t.Error("expected cached loader error, got:", err)
+		}
+	}
+	if calls != 1 {
+		t.Error("negative result should be cached, got calls:", calls)
+	}
+
+	<-time.After(25 * time.Millisecond)
+	tc.GetOrLoad(context.Background(), "a", loader)
+	if calls != 2 {
+		t.Error("negative result should expire, got calls:", calls)
+	}
+
+	// a successful Set replaces the negative result
+	tc.Set("a", 1, DefaultExpiration)
+	if v, err := tc.GetOrLoad(context.Background(), "a", loader); err != nil || v != 1 {
+		t.Error("expected value set after negative result, got:", v, err)
+	}
+}
+
+func TestGetOrLoadPanic(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0)
+	_, err := tc.GetOrLoad(context.Background(), "a", func(ctx context.Context) (int, time.Duration, error) {
+		panic("boom")
+	})
+	if err == nil {
+		t.Fatal("expected panic to be returned as error")
+	}
+	if _, found := tc.Get("a"); found {
+		t.Error("a panicking loader should not cache a value")
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	tc := NewCache[string, int](10*time.Millisecond, 0, WithStaleTTL(time.Second))
+	var calls int32
+	refreshed := make(chan struct{}, 1)
+	tc.SetLoader(func(ctx context.Context, k string) (int, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		refreshed <- struct{}{}
+		return 2, DefaultExpiration, nil
+	})
+	tc.Set("a", 1, DefaultExpiration)
+
+	<-time.After(15 * time.Millisecond)
+	v, found := tc.Get("a")
+	if !found || v != 1 {
+		t.Fatal("expected stale value 1, got:", v, found)
+	}
+	// further reads while the refresh is in flight do not trigger another one
+	tc.Get("a")
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("stale item was not refreshed")
+	}
+	// wait for the refreshed value to be stored
+	for i := 0; i < 100; i++ {
+		if v, _ = tc.Get("a"); v == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if v != 2 {
+		t.Error("expected refreshed value 2, got:", v)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Error("expected a single refresh, got:", n)
+	}
+}
+
+func TestStaleWithoutLoader(t *testing.T) {
+	tc := NewCache[string, int](10*time.Millisecond, 0, WithStaleTTL(time.Second))
+	tc.Set("a", 1, DefaultExpiration)
+	<-time.After(15 * time.Millisecond)
+	if _, found := tc.Get("a"); found {
+		t.Error("expired item should not be served stale without a loader")
+	}
+}
+
+func TestRefreshAhead(t *testing.T) {
+	tc := NewCache[string, int](50*time.Millisecond, 0, WithRefreshAhead(0.5))
+	refreshed := make(chan int, 1)
+	tc.SetLoader(func(ctx context.Context, k string) (int, time.Duration, error) {
+		refreshed <- 2
+		return 2, DefaultExpiration, nil
+	})
+	tc.Set("a", 1, DefaultExpiration)
+
+	tc.Get("a")
+	select {
+	case <-refreshed:
+		t.Fatal("fresh item should not be refreshed")
+	default:
+	}
+
+	<-time.After(30 * time.Millisecond)
+	if v, found := tc.Get("a"); !found || v != 1 {
+		t.Fatal("expected current value 1 while refreshing ahead, got:", v, found)
+	}
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("item was not refreshed ahead of its expiry")
+	}
+}
+
+func TestGetOrLoadCallerCancelled(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0, WithNegativeTTL(time.Minute))
+	defer tc.Close()
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (int, time.Duration, error) {
+		select {
+		case <-release:
+			return 1, DefaultExpiration, nil
+		case <-ctx.Done():
+			return 0, 0, ctx.Err()
+		}
+	}
+
+	// the first caller gives up while the load is in flight
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := tc.GetOrLoad(ctx, "a", loader)
+		done <- err
+	}()
+	<-time.After(5 * time.Millisecond)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatal("expected the cancelled caller to stop waiting, got:", err)
+	}
+
+	// the shared load carries on for the other callers
+	close(release)
+	v, err := tc.GetOrLoad(context.Background(), "a", loader)
+	if err != nil || v != 1 {
+		t.Error("expected the load to complete despite the cancelled caller, got:", v, err)
+	}
+}
+
+func TestGetOrLoadContextErrorNotCached(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0, WithNegativeTTL(time.Minute))
+	var calls int32
+	loader := func(ctx context.Context) (int, time.Duration, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return 0, 0, context.DeadlineExceeded
+		}
+		return 1, DefaultExpiration, nil
+	}
+
+	if _, err := tc.GetOrLoad(context.Background(), "a", loader); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("expected the loader error, got:", err)
+	}
+	if v, err := tc.GetOrLoad(context.Background(), "a", loader); err != nil || v != 1 {
+		t.Error("expected a context error not to be cached as negative, got:", v, err)
+	}
+}
+
+func TestGetOrLoadClose(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0)
+	started := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		_, err := tc.GetOrLoad(context.Background(), "a", func(ctx context.Context) (int, time.Duration, error) {
+			close(started)
+			<-ctx.Done()
+			return 0, 0, ctx.Err()
+		})
+		done <- err
+	}()
+	<-started
+	tc.Close()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Error("expected Close to cancel the load, got:", err)
+	}
+}
+
+func TestGetOrLoadRecheckDoesNotRefresh(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0, WithRefreshAhead(1))
+	tc.SetLoader(func(ctx context.Context, k string) (int, time.Duration, error) {
+		return 3, DefaultExpiration, nil
+	})
+
+	// holding loadMu stops GetOrLoad between its first lookup and the re-check
+	tc.loadMu.Lock()
+	result := make(chan int, 1)
+	go func() {
+		v, _ := tc.GetOrLoad(context.Background(), "a", func(ctx context.Context) (int, time.Duration, error) {
+			return 1, DefaultExpiration, nil
+		})
+		result <- v
+	}()
+	for tc.Stats().Misses == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// a concurrent load stores an item inside its refresh window meanwhile
+	tc.Set("a", 2, time.Second)
+	tc.loadMu.Unlock()
+
+	select {
+	case v := <-result:
+		if v != 2 {
+			t.Error("expected the concurrently loaded value 2, got:", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("GetOrLoad deadlocked on the re-check")
+	}
+	// loads are still possible afterwards
+	if v, err := tc.GetOrLoad(context.Background(), "b", func(ctx context.Context) (int, time.Duration, error) {
+		return 4, DefaultExpiration, nil
+	}); err != nil || v != 4 {
+		t.Error("unexpected load result:", v, err)
+	}
+}
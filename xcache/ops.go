@@ -0,0 +1,197 @@
+package xcache
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	// ErrExists is returned by Add when the key already holds an unexpired item
+	ErrExists = errors.New("xcache: item already exists")
+	// ErrNotFound is returned when the key holds no unexpired item
+	ErrNotFound = errors.New("xcache: item not found")
+	// ErrNotNumeric is returned by Increment and Decrement for non numeric items
+	ErrNotNumeric = errors.New("xcache: item is not numeric")
+)
+
+// lookup returns the unexpired entry of k, the caller must hold the lock
+func (c *cache[K, V]) lookup(k K) (entry[V], bool) {
+	item, found := c.items[k]
+	if !found || (item.Expiration > 0 && time.Now().UnixNano() > item.Expiration) {
+		return entry[V]{}, false
+	}
+	return item, true
+}
+
+// GetWithExpiration returns an item and the time it expires at, which is
+// the zero time for items that never expire
+func (c *cache[K, V]) GetWithExpiration(k K) (V, time.Time, bool) {
+	c.mu.RLock()
+	item, found := c.lookup(k)
+	c.mu.RUnlock()
+	if !found {
+		var zero V
+		c.stats.miss()
+		return zero, time.Time{}, false
+	}
+	c.stats.hit()
+	if item.Expiration > 0 {
+		return item.Object, time.Unix(0, item.Expiration), true
+	}
+	return item.Object, time.Time{}, true
+}
+
+// Add an item to the cache only if the key holds no unexpired item yet,
+// otherwise ErrExists is returned
+func (c *cache[K, V]) Add(k K, x V, d time.Duration) error {
+	return c.store(k, c.newEntry(x, d, 1, nil), func(found bool) error {
+		if found {
+			return fmt.Errorf("%w: %v", ErrExists, k)
+		}
+		return nil
+	})
+}
+
+// Replace the item of a key only if it holds an unexpired item,
+// otherwise ErrNotFound is returned
+func (c *cache[K, V]) Replace(k K, x V, d time.Duration) error {
+	return c.store(k, c.newEntry(x, d, 1, nil), func(found bool) error {
+		if !found {
+			return fmt.Errorf("%w: %v", ErrNotFound, k)
+		}
+		return nil
+	})
+}
+
+// CompareAndSwap replaces the item of k by x if it currently holds old, keeping
+// its expiration. It reports whether the swap happened, non comparable
+// values never match
+func (c *cache[K, V]) CompareAndSwap(k K, old, x V) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, found := c.lookup(k)
+	if !found || !equal(item.Object, old) {
+		return false
+	}
+	item.Object = x
+	c.items[k] = item
+	c.stats.set()
+	return true
+}
+
+// Increment adds n to the numeric item of k, keeping its expiration, and
+// returns the new value. Both the item and n must be of the same built-in
+// integer or float type, otherwise ErrNotNumeric is returned
+func (c *cache[K, V]) Increment(k K, n V) (V, error) {
+	return c.modify(k, n, false)
+}
+
+// Decrement subtracts n from the numeric item of k, see Increment
+func (c *cache[K, V]) Decrement(k K, n V) (V, error) {
+	return c.modify(k, n, true)
+}
+
+func (c *cache[K, V]) modify(k K, n V, neg bool) (V, error) {
+	var zero V
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, found := c.lookup(k)
+	if !found {
+		return zero, fmt.Errorf("%w: %v", ErrNotFound, k)
+	}
+	res, err := arith(any(item.Object), any(n), neg)
+	if err != nil {
+		return zero, fmt.Errorf("%w: %v", err, k)
+	}
+	item.Object = res.(V)
+	c.items[k] = item
+	c.stats.set()
+	return item.Object, nil
+}
+
+func equal(a, b any) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if !reflect.TypeOf(a).Comparable() || !reflect.ValueOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// arith adds or subtracts b to a, which must share a numeric type. Named
+// types like time.Duration are matched on their underlying kind
+func arith(a, b any, neg bool) (any, error) {
+	x, y := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !x.IsValid() || !numeric(x.Kind()) {
+		return nil, fmt.Errorf("%w: %T", ErrNotNumeric, a)
+	}
+	if !y.IsValid() || y.Type() != x.Type() {
+		return nil, fmt.Errorf("%w: cannot add %T to %T", ErrNotNumeric, b, a)
+	}
+
+	res := reflect.New(x.Type()).Elem()
+	switch x.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if neg {
+			res.SetInt(x.Int() - y.Int())
+		} else {
+			res.SetInt(x.Int() + y.Int())
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if neg {
+			res.SetUint(x.Uint() - y.Uint())
+		} else {
+			res.SetUint(x.Uint() + y.Uint())
+		}
+	default:
+		if neg {
+			res.SetFloat(x.Float() - y.Float())
+		} else {
+			res.SetFloat(x.Float() + y.Float())
+		}
+	}
+	return res.Interface(), nil
+}
+
+func numeric(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// GetWithExpiration returns an item and the time it expires at, see Cache.GetWithExpiration
+func (sc *shardedCache[K, V]) GetWithExpiration(k K) (V, time.Time, bool) {
+	return sc.shard(k).GetWithExpiration(k)
+}
+
+// Add an item only if the key holds none yet, see Cache.Add
+func (sc *shardedCache[K, V]) Add(k K, x V, d time.Duration) error {
+	return sc.shard(k).Add(k, x, d)
+}
+
+// Replace the item of a key only if it holds one, see Cache.Replace
+func (sc *shardedCache[K, V]) Replace(k K, x V, d time.Duration) error {
+	return sc.shard(k).Replace(k, x, d)
+}
+
+// CompareAndSwap replaces the item of k if it holds old, see Cache.CompareAndSwap
+func (sc *shardedCache[K, V]) CompareAndSwap(k K, old, x V) bool {
+	return sc.shard(k).CompareAndSwap(k, old, x)
+}
+
+// Increment adds n to the numeric item of k, see Cache.Increment
+func (sc *shardedCache[K, V]) Increment(k K, n V) (V, error) {
+	return sc.shard(k).Increment(k, n)
+}
+
+// Decrement subtracts n from the numeric item of k, see Cache.Decrement
+func (sc *shardedCache[K, V]) Decrement(k K, n V) (V, error) {
+	return sc.shard(k).Decrement(k, n)
+}
@@ -0,0 +1,152 @@
+package xcache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAddReplace(t *testing.T) {
+	tc := NewCache[string, string](DefaultExpiration, 0)
+
+	if err := tc.Replace("a", "x", DefaultExpiration); !errors.Is(err, ErrNotFound) {
+		t.Error("expected ErrNotFound replacing a missing item, got:", err)
+	}
+	if err := tc.Add("a", "x", DefaultExpiration); err != nil {
+		t.Error("couldn't add a:", err)
+	}
+	if err := tc.Add("a", "y", DefaultExpiration); !errors.Is(err, ErrExists) {
+		t.Error("expected ErrExists adding an existing item, got:", err)
+	}
+	if err := tc.Replace("a", "z", DefaultExpiration); err != nil {
+		t.Error("couldn't replace a:", err)
+	}
+	if v, _ := tc.Get("a"); v != "z" {
+		t.Error("expected a to be replaced by z, got:", v)
+	}
+
+	tc.Set("b", "x", time.Millisecond)
+	<-time.After(2 * time.Millisecond)
+	if err := tc.Add("b", "y", DefaultExpiration); err != nil {
+		t.Error("expected expired item to be overwritten by add, got:", err)
+	}
+}
+
+func TestIncrementDecrement(t *testing.T) {
+	tc := NewCache[string, int64](DefaultExpiration, 0)
+	if _, err := tc.Increment("a", 1); !errors.Is(err, ErrNotFound) {
+		t.Error("expected ErrNotFound incrementing a missing item, got:", err)
+	}
+
+	tc.Set("a", 1, time.Hour)
+	_, exp, _ := tc.GetWithExpiration("a")
+
+	v, err := tc.Increment("a", 2)
+	if err != nil || v != 3 {
+		t.Error("unexpected increment result:", v, err)
+	}
+	v, err = tc.Decrement("a", 5)
+	if err != nil || v != -2 {
+		t.Error("unexpected decrement result:", v, err)
+	}
+	if _, e, _ := tc.GetWithExpiration("a"); !e.Equal(exp) {
+		t.Error("increment should keep the expiration, got:", e, exp)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tc.Increment("a", 1)
+		}()
+	}
+	wg.Wait()
+	if v, _ := tc.Get("a"); v != 98 {
+		t.Error("concurrent increments were lost, got:", v)
+	}
+}
+
+func TestIncrementUntyped(t *testing.T) {
+	tc := New(DefaultExpiration, 0)
+	tc.Set("int", 1, DefaultExpiration)
+	tc.Set("float", 1.5, DefaultExpiration)
+	tc.Set("string", "a", DefaultExpiration)
+
+	if v, err := tc.Increment("int", 2); err != nil || v != 3 {
+		t.Error("unexpected int increment result:", v, err)
+	}
+	if v, err := tc.Decrement("float", 0.5); err != nil || v != 1.0 {
+		t.Error("unexpected float decrement result:", v, err)
+	}
+	if _, err := tc.Increment("int", 2.5); !errors.Is(err, ErrNotNumeric) {
+		t.Error("expected ErrNotNumeric for mismatching types, got:", err)
+	}
+	if _, err := tc.Increment("string", 1); !errors.Is(err, ErrNotNumeric) {
+		t.Error("expected ErrNotNumeric for a string, got:", err)
+	}
+}
+
+type count uint8
+
+func TestIncrementNamedTypes(t *testing.T) {
+	tc := NewCache[string, time.Duration](DefaultExpiration, 0)
+	tc.Set("a", time.Second, DefaultExpiration)
+	if v, err := tc.Increment("a", time.Minute); err != nil || v != time.Minute+time.Second {
+		t.Error("unexpected duration increment result:", v, err)
+	}
+
+	cc := NewCache[string, count](DefaultExpiration, 0)
+	cc.Set("a", 255, DefaultExpiration)
+	if v, err := cc.Increment("a", 2); err != nil || v != 1 {
+		t.Error("expected the named uint8 to wrap around like native arithmetic, got:", v, err)
+	}
+
+	uc := New(DefaultExpiration, 0)
+	uc.Set("a", count(1), DefaultExpiration)
+	if v, err := uc.Decrement("a", count(1)); err != nil || v != count(0) {
+		t.Error("unexpected untyped named decrement result:", v, err)
+	}
+	if _, err := uc.Increment("a", uint8(1)); !errors.Is(err, ErrNotNumeric) {
+		t.Error("expected ErrNotNumeric for the underlying instead of the named type, got:", err)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	tc := New(DefaultExpiration, 0)
+	tc.Set("a", "x", DefaultExpiration)
+	tc.Set("b", []string{"x"}, DefaultExpiration)
+
+	if tc.CompareAndSwap("a", "y", "z") {
+		t.Error("swapped although the old value did not match")
+	}
+	if !tc.CompareAndSwap("a", "x", "z") {
+		t.Error("did not swap although the old value matched")
+	}
+	if v, _ := tc.Get("a"); v != "z" {
+		t.Error("expected a to be z, got:", v)
+	}
+	if tc.CompareAndSwap("b", []string{"x"}, "z") {
+		t.Error("non comparable values should never match")
+	}
+	if tc.CompareAndSwap("missing", nil, "z") {
+		t.Error("swapped a missing item")
+	}
+}
+
+func TestGetWithExpiration(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0)
+	tc.Set("a", 1, NoExpiration)
+	tc.Set("b", 2, time.Minute)
+
+	if v, exp, found := tc.GetWithExpiration("a"); !found || v != 1 || !exp.IsZero() {
+		t.Error("unexpected result for a:", v, exp, found)
+	}
+	if _, exp, found := tc.GetWithExpiration("b"); !found || time.Until(exp) <= 0 {
+		t.Error("unexpected expiration for b:", exp, found)
+	}
+	if _, _, found := tc.GetWithExpiration("c"); found {
+		t.Error("found c which was never set")
+	}
+}
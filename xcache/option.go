@@ -0,0 +1,97 @@
+package xcache
+
+import (
+	"context"
+	"time"
+)
+
+// Option configures the optional behaviour of a cache
+type Option func(o *options)
+
+type options struct {
+	maxEntries   int
+	maxCost      int64
+	policy       Policy
+	negativeTTL  time.Duration
+	staleTTL     time.Duration
+	refreshAhead float64
+	statsHook    StatsHook
+	ctx          context.Context
+}
+
+// WithMaxEntries bounds the number of items held by the cache. Once the limit is
+// exceeded, items are evicted according to the configured Policy (LRU by default)
+func WithMaxEntries(n int) Option {
+	return func(o *options) {
+		o.maxEntries = n
+	}
+}
+
+// WithMaxCost bounds the summed cost of the items held by the cache. Items added
+// through Set weigh 1, use SetWithCost to assign a custom weight
+func WithMaxCost(n int64) Option {
+	return func(o *options) {
+		o.maxCost = n
+	}
+}
+
+// WithPolicy selects which item is evicted first when the cache is over capacity
+func WithPolicy(p Policy) Option {
+	return func(o *options) {
+		o.policy = p
+	}
+}
+
+// WithNegativeTTL caches errors returned by the GetOrLoad loader for d, so a
+// failing backend is not hammered for every miss
+func WithNegativeTTL(d time.Duration) Option {
+	return func(o *options) {
+		o.negativeTTL = d
+	}
+}
+
+// WithStaleTTL keeps serving expired items for d while the loader registered
+// with SetLoader refreshes them in the background (stale-while-revalidate)
+func WithStaleTTL(d time.Duration) Option {
+	return func(o *options) {
+		o.staleTTL = d
+	}
+}
+
+// WithRefreshAhead reloads items through the loader registered with SetLoader
+// when they are read within the given fraction of their lifetime before
+// expiry, eg 0.2 refreshes an item of 10 minutes during its last 2 minutes
+func WithRefreshAhead(fraction float64) Option {
+	return func(o *options) {
+		o.refreshAhead = fraction
+	}
+}
+
+// WithStatsHook forwards every cache event to h, eg to feed a metrics collector
+func WithStatsHook(h StatsHook) Option {
+	return func(o *options) {
+		o.statsHook = h
+	}
+}
+
+// WithContext bounds the lifetime of the janitor and background refreshes to
+// ctx, as an alternative to calling Close
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		ctx: context.Background(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *options) bounded() bool {
+	return o.maxEntries > 0 || o.maxCost > 0
+}
@@ -0,0 +1,204 @@
+package xcache
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+)
+
+// snapshot is the saved form of an item including the bookkeeping restored
+// by Load. Snapshots of plain items, as written by earlier versions, decode
+// into it as well
+type snapshot[V any] struct {
+	Object     V
+	Expiration int64
+	Cost       int64
+	TTL        time.Duration
+	Tags       []string
+}
+
+// Save writes the unexpired items of the cache gob encoded to w, including
+// their tags and cost. Caches with
+// an interface value type register the concrete types of their items with gob
+// so they can be encoded, custom types nested deeper must be registered by the
+// caller with gob.Register
+func (c *cache[K, V]) Save(w io.Writer) error {
+	items := make(map[K]snapshot[V])
+	c.snapshot(items)
+	return encodeItems(w, items)
+}
+
+// snapshot adds the unexpired items of the cache to items
+func (c *cache[K, V]) snapshot(items map[K]snapshot[V]) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := time.Now().UnixNano()
+	for k, v := range c.items {
+		if v.Expiration > 0 && now > v.Expiration {
+			continue
+		}
+		items[k] = snapshot[V]{
+			Object:     v.Object,
+			Expiration: v.Expiration,
+			Cost:       v.cost,
+			TTL:        v.ttl,
+			Tags:       v.tags,
+		}
+	}
+}
+
+// SaveFile writes the unexpired items of the cache to fname, see Save. The file
+// is replaced atomically so a crash never leaves a truncated snapshot behind
+func (c *cache[K, V]) SaveFile(fname string) error {
+	return saveFile(fname, c.Save)
+}
+
+// Load adds the gob encoded items read from r to the cache. Items which
+// expired in the meantime and keys which already hold an unexpired item are skipped.
+// Caches with an interface value type, like those returned by New, can only
+// decode concrete types registered with gob.Register beforehand. A process
+// loading a snapshot before saving any, eg right after a deploy, must register
+// its custom value types itself, usually in an init function
+func (c *cache[K, V]) Load(r io.Reader) error {
+	items, err := decodeItems[K, V](r)
+	if err != nil {
+		return err
+	}
+	c.restore(items)
+	return nil
+}
+
+// LoadFile adds the items saved to fname with SaveFile to the cache, see Load
+func (c *cache[K, V]) LoadFile(fname string) error {
+	return loadFile(fname, c.Load)
+}
+
+func (c *cache[K, V]) restore(items map[K]snapshot[V]) {
+	now := time.Now().UnixNano()
+	c.mu.Lock()
+	for k, v := range items {
+		if v.Expiration > 0 && now > v.Expiration {
+			continue
+		}
+		if old, found := c.items[k]; found && (old.Expiration == 0 || now <= old.Expiration) {
+			continue
+		}
+		e := entry[V]{
+			TypedItem: TypedItem[V]{Object: v.Object, Expiration: v.Expiration},
+			cost:      v.Cost,
+			ttl:       v.TTL,
+			tags:      v.Tags,
+		}
+		// snapshots without bookkeeping hold items set with the defaults
+		if e.cost <= 0 {
+			e.cost = 1
+		}
+		if e.ttl <= 0 && v.Expiration > 0 {
+			e.ttl = time.Duration(v.Expiration - now)
+		}
+		c.set(k, e)
+	}
+	evicted := c.evict()
+	c.mu.Unlock()
+	c.notify(evicted)
+}
+
+// Save writes the unexpired items of all segments to w, see Cache.Save
+func (sc *shardedCache[K, V]) Save(w io.Writer) error {
+	items := make(map[K]snapshot[V])
+	for _, c := range sc.shards {
+		c.snapshot(items)
+	}
+	return encodeItems(w, items)
+}
+
+// SaveFile writes the unexpired items of all segments to fname, see Cache.SaveFile
+func (sc *shardedCache[K, V]) SaveFile(fname string) error {
+	return saveFile(fname, sc.Save)
+}
+
+// Load adds the items read from r to their segments, see Cache.Load
+func (sc *shardedCache[K, V]) Load(r io.Reader) error {
+	items, err := decodeItems[K, V](r)
+	if err != nil {
+		return err
+	}
+	parts := make(map[*cache[K, V]]map[K]snapshot[V], len(sc.shards))
+	for k, v := range items {
+		c := sc.shard(k)
+		if parts[c] == nil {
+			parts[c] = make(map[K]snapshot[V])
+		}
+		parts[c][k] = v
+	}
+	for c, part := range parts {
+		c.restore(part)
+	}
+	return nil
+}
+
+// LoadFile adds the items saved to fname to their segments, see Cache.LoadFile
+func (sc *shardedCache[K, V]) LoadFile(fname string) error {
+	return loadFile(fname, sc.Load)
+}
+
+func encodeItems[K comparable, V any](w io.Writer, items map[K]snapshot[V]) (err error) {
+	if reflect.TypeOf((*V)(nil)).Elem().Kind() == reflect.Interface {
+		defer func() {
+			if x := recover(); x != nil {
+				err = fmt.Errorf("xcache: registering item types with gob: %v", x)
+			}
+		}()
+		for _, v := range items {
+			if any(v.Object) != nil {
+				gob.Register(v.Object)
+			}
+		}
+	}
+
+	if err = gob.NewEncoder(w).Encode(&items); err != nil {
+		return fmt.Errorf("xcache: encoding items: %w", err)
+	}
+	return nil
+}
+
+func decodeItems[K comparable, V any](r io.Reader) (map[K]snapshot[V], error) {
+	items := make(map[K]snapshot[V])
+	if err := gob.NewDecoder(r).Decode(&items); err != nil {
+		return nil, fmt.Errorf("xcache: decoding items: %w", err)
+	}
+	return items, nil
+}
+
+func saveFile(fname string, save func(io.Writer) error) error {
+	tmp := fname + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("xcache: create %s: %w", tmp, err)
+	}
+	if err = save(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("xcache: close %s: %w", tmp, err)
+	}
+	if err = os.Rename(tmp, fname); err != nil {
+		return fmt.Errorf("xcache: rename %s: %w", tmp, err)
+	}
+	return nil
+}
+
+func loadFile(fname string, load func(io.Reader) error) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return fmt.Errorf("xcache: open %s: %w", fname, err)
+	}
+	defer f.Close()
+	return load(f)
+}
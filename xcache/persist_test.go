@@ -0,0 +1,127 @@
+package xcache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveLoad(t *testing.T) {
+	tc := New(DefaultExpiration, 0)
+	tc.Set("a", "a", DefaultExpiration)
+	tc.Set("b", &TestStruct{Num: 2}, DefaultExpiration)
+	tc.Set("c", 3, 20*time.Millisecond)
+	tc.Set("d", 4, time.Hour)
+
+	buf := new(bytes.Buffer)
+	if err := tc.Save(buf); err != nil {
+		t.Fatal("couldn't save cache:", err)
+	}
+
+	<-time.After(25 * time.Millisecond)
+
+	oc := New(DefaultExpiration, 0)
+	oc.Set("a", "kept", DefaultExpiration)
+	if err := oc.Load(buf); err != nil {
+		t.Fatal("couldn't load cache:", err)
+	}
+
+	if x, _ := oc.Get("a"); x != "kept" {
+		t.Error("existing unexpired item was overwritten by load:", x)
+	}
+	if x, found := oc.Get("b"); !found || x.(*TestStruct).Num != 2 {
+		t.Error("b was not restored:", x)
+	}
+	if _, found := oc.Get("c"); found {
+		t.Error("c expired in the meantime and should not be restored")
+	}
+	items := oc.Items()
+	if d, found := items["d"]; !found || d.Expiration != tc.Items()["d"].Expiration {
+		t.Error("d was not restored with its original expiration:", d)
+	}
+}
+
+func TestSaveLoadFile(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "cache.gob")
+	tc := NewCache[string, TestStruct](DefaultExpiration, 0)
+	tc.Set("a", TestStruct{Num: 1, Children: []*TestStruct{{Num: 2}}}, DefaultExpiration)
+
+	if err := tc.SaveFile(fname); err != nil {
+		t.Fatal("couldn't save cache to file:", err)
+	}
+
+	oc := NewSharded[string, TestStruct](4, DefaultExpiration, 0)
+	if err := oc.LoadFile(fname); err != nil {
+		t.Fatal("couldn't load cache from file:", err)
+	}
+	x, found := oc.Get("a")
+	if !found || x.Num != 1 || len(x.Children) != 1 || x.Children[0].Num != 2 {
+		t.Error("a was not restored:", x)
+	}
+
+	if err := oc.LoadFile(filepath.Join(t.TempDir(), "missing.gob")); err == nil {
+		t.Error("expected an error loading a missing file")
+	}
+}
+
+func TestSaveLoadTagsAndCost(t *testing.T) {
+	tc := NewSharded[string, int](4, DefaultExpiration, 0)
+	tc.SetWithTags("a", 1, DefaultExpiration, "user:1")
+	tc.SetWithTags("b", 2, DefaultExpiration, "user:1", "team")
+	tc.SetWithCost("c", 3, DefaultExpiration, 8)
+
+	buf := new(bytes.Buffer)
+	if err := tc.Save(buf); err != nil {
+		t.Fatal("couldn't save cache:", err)
+	}
+
+	oc := NewCache[string, int](DefaultExpiration, 0, WithMaxCost(10))
+	oc.SetWithCost("d", 4, DefaultExpiration, 1)
+	if err := oc.Load(buf); err != nil {
+		t.Fatal("couldn't load cache:", err)
+	}
+
+	// a, b, c and d cost 11, the least recently used d has to go
+	if _, found := oc.Get("d"); found {
+		t.Error("expected the restored costs to evict d")
+	}
+	if n := oc.InvalidateTag("user:1"); n != 2 {
+		t.Error("expected the restored tags to invalidate a and b, got:", n)
+	}
+	if n := oc.InvalidateTag("team"); n != 0 {
+		t.Error("expected b to be untagged once removed, got:", n)
+	}
+	if v, found := oc.Get("c"); !found || v != 3 {
+		t.Error("c was not restored:", v, found)
+	}
+}
+
+// fixtureValue is only ever decoded, testdata/registered.gob was written by
+// another process which registered it as xcache.fixtureValue
+type fixtureValue struct {
+	Name  string
+	Count int
+}
+
+func TestLoadRegisteredTypes(t *testing.T) {
+	// the type name of testdata/unregistered.gob is registered nowhere
+	oc := New(DefaultExpiration, 0)
+	err := oc.LoadFile("testdata/unregistered.gob")
+	if err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Error("expected loading an unregistered type to fail, got:", err)
+	}
+
+	gob.RegisterName("xcache.fixtureValue", fixtureValue{})
+	if err = oc.LoadFile("testdata/registered.gob"); err != nil {
+		t.Fatal("couldn't load cache with registered types:", err)
+	}
+	if x, found := oc.Get("a"); !found || x.(fixtureValue) != (fixtureValue{Name: "a", Count: 1}) {
+		t.Error("a was not restored:", x)
+	}
+	if x, found := oc.Get("b"); !found || x != "plain" {
+		t.Error("b was not restored:", x)
+	}
+}
@@ -0,0 +1,209 @@
+package xcache
+
+import (
+	"container/heap"
+	"container/list"
+	"sync"
+)
+
+// Policy selects the victim once a bounded cache is over capacity
+type Policy int
+
+const (
+	// LRU evicts the least recently used item
+	LRU Policy = iota
+	// LFU evicts the least frequently used item, the oldest one on a tie
+	LFU
+	// FIFO evicts the oldest inserted item regardless of its usage
+	FIFO
+)
+
+// EvictionReason tells the onEvicted callback why an item left the cache
+type EvictionReason int
+
+const (
+	// Deleted the item was removed manually
+	Deleted EvictionReason = iota
+	// Expired the item outlived its expiration
+	Expired
+	// Capacity the item was evicted to respect the cache bounds
+	Capacity
+	// Invalidated the item was removed through one of its tags
+	Invalidated
+
+	numEvictionReasons = iota
+)
+
+func (r EvictionReason) String() string {
+	switch r {
+	case Deleted:
+		return "deleted"
+	case Expired:
+		return "expired"
+	case Capacity:
+		return "capacity"
+	case Invalidated:
+		return "invalidated"
+	default:
+		return "unknown"
+	}
+}
+
+// evictionPolicy tracks key usage to pick the next victim. Implementations
+// guard themselves as Get touches keys while only holding the read lock
+type evictionPolicy[K comparable] interface {
+	add(k K)
+	touch(k K)
+	remove(k K)
+	victim() (K, bool)
+}
+
+func newPolicy[K comparable](p Policy) evictionPolicy[K] {
+	switch p {
+	case LFU:
+		return &lfuPolicy[K]{
+			entries: make(map[K]*lfuEntry[K]),
+		}
+	case FIFO:
+		return newListPolicy[K](false)
+	default:
+		return newListPolicy[K](true)
+	}
+}
+
+// listPolicy orders keys from newest (front) to oldest (back). Moving touched
+// keys to the front yields LRU, leaving them in place yields FIFO
+type listPolicy[K comparable] struct {
+	mu          sync.Mutex
+	ll          *list.List
+	elems       map[K]*list.Element
+	moveOnTouch bool
+}
+
+func newListPolicy[K comparable](moveOnTouch bool) *listPolicy[K] {
+	return &listPolicy[K]{
+		ll:          list.New(),
+		elems:       make(map[K]*list.Element),
+		moveOnTouch: moveOnTouch,
+	}
+}
+
+func (p *listPolicy[K]) add(k K) {
+	p.mu.Lock()
+	p.elems[k] = p.ll.PushFront(k)
+	p.mu.Unlock()
+}
+
+func (p *listPolicy[K]) touch(k K) {
+	if !p.moveOnTouch {
+		return
+	}
+	p.mu.Lock()
+	if e, ok := p.elems[k]; ok {
+		p.ll.MoveToFront(e)
+	}
+	p.mu.Unlock()
+}
+
+func (p *listPolicy[K]) remove(k K) {
+	p.mu.Lock()
+	if e, ok := p.elems[k]; ok {
+		p.ll.Remove(e)
+		delete(p.elems, k)
+	}
+	p.mu.Unlock()
+}
+
+func (p *listPolicy[K]) victim() (K, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e := p.ll.Back(); e != nil {
+		return e.Value.(K), true
+	}
+	var zero K
+	return zero, false
+}
+
+type lfuEntry[K comparable] struct {
+	key   K
+	freq  uint64
+	seq   uint64
+	index int
+}
+
+// lfuHeap is a min-heap on access frequency, falling back to insertion order
+type lfuHeap[K comparable] []*lfuEntry[K]
+
+func (h lfuHeap[K]) Len() int { return len(h) }
+
+func (h lfuHeap[K]) Less(i, j int) bool {
+	if h[i].freq == h[j].freq {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].freq < h[j].freq
+}
+
+func (h lfuHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap[K]) Push(x any) {
+	e := x.(*lfuEntry[K])
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap[K]) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
+
+type lfuPolicy[K comparable] struct {
+	mu      sync.Mutex
+	heap    lfuHeap[K]
+	entries map[K]*lfuEntry[K]
+	seq     uint64
+}
+
+func (p *lfuPolicy[K]) add(k K) {
+	p.mu.Lock()
+	p.seq++
+	e := &lfuEntry[K]{key: k, freq: 1, seq: p.seq}
+	p.entries[k] = e
+	heap.Push(&p.heap, e)
+	p.mu.Unlock()
+}
+
+func (p *lfuPolicy[K]) touch(k K) {
+	p.mu.Lock()
+	if e, ok := p.entries[k]; ok {
+		e.freq++
+		heap.Fix(&p.heap, e.index)
+	}
+	p.mu.Unlock()
+}
+
+func (p *lfuPolicy[K]) remove(k K) {
+	p.mu.Lock()
+	if e, ok := p.entries[k]; ok {
+		heap.Remove(&p.heap, e.index)
+		delete(p.entries, k)
+	}
+	p.mu.Unlock()
+}
+
+func (p *lfuPolicy[K]) victim() (K, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.heap) > 0 {
+		return p.heap[0].key, true
+	}
+	var zero K
+	return zero, false
+}
@@ -0,0 +1,108 @@
+package xcache
+
+import (
+	"testing"
+)
+
+func fill(tc *TypedCache[string, int], keys ...string) {
+	for i, k := range keys {
+		tc.Set(k, i, DefaultExpiration)
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0, WithMaxEntries(2))
+	var evicted []string
+	tc.OnEvictedWithReason(func(k string, v int, reason EvictionReason) {
+		if reason != Capacity {
+			t.Error("unexpected eviction reason:", reason)
+		}
+		evicted = append(evicted, k)
+	})
+
+	fill(tc, "a", "b")
+	tc.Get("a")
+	tc.Set("c", 3, DefaultExpiration)
+
+	if _, found := tc.Get("b"); found {
+		t.Error("b should have been evicted as least recently used")
+	}
+	if _, found := tc.Get("a"); !found {
+		t.Error("a should have been kept as it was used recently")
+	}
+	if len(evicted) != 1 || evicted[0] != "b" {
+		t.Error("expected only b to be evicted, got:", evicted)
+	}
+	if n := tc.ItemCount(); n != 2 {
+		t.Error("expected 2 items, got:", n)
+	}
+}
+
+func TestLFUEviction(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0, WithMaxEntries(2), WithPolicy(LFU))
+
+	fill(tc, "a", "b")
+	tc.Get("a")
+	tc.Get("a")
+	tc.Get("b")
+	tc.Set("c", 3, DefaultExpiration)
+
+	if _, found := tc.Get("a"); !found {
+		t.Error("a should have been kept as most frequently used")
+	}
+	if _, found := tc.Get("b"); !found {
+		t.Error("b should have been kept as it was used more often than c")
+	}
+	if _, found := tc.Get("c"); found {
+		t.Error("c should have been evicted as least frequently used")
+	}
+}
+
+func TestFIFOEviction(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0, WithMaxEntries(2), WithPolicy(FIFO))
+
+	fill(tc, "a", "b")
+	tc.Get("a")
+	tc.Set("c", 3, DefaultExpiration)
+
+	if _, found := tc.Get("a"); found {
+		t.Error("a should have been evicted as first inserted")
+	}
+	if _, found := tc.Get("b"); !found {
+		t.Error("b was not found")
+	}
+}
+
+func TestMaxCostEviction(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0, WithMaxCost(10))
+
+	tc.SetWithCost("a", 1, DefaultExpiration, 4)
+	tc.SetWithCost("b", 2, DefaultExpiration, 4)
+	tc.SetWithCost("c", 3, DefaultExpiration, 4)
+
+	if _, found := tc.Get("a"); found {
+		t.Error("a should have been evicted to respect the max cost")
+	}
+	if n := tc.ItemCount(); n != 2 {
+		t.Error("expected 2 items, got:", n)
+	}
+
+	// overwriting adjusts the cost instead of adding to it
+	tc.SetWithCost("b", 2, DefaultExpiration, 6)
+	if n := tc.ItemCount(); n != 2 {
+		t.Error("expected 2 items after overwrite, got:", n)
+	}
+}
+
+func TestDeleteReason(t *testing.T) {
+	tc := NewCache[string, int](DefaultExpiration, 0)
+	var reason EvictionReason = -1
+	tc.OnEvictedWithReason(func(_ string, _ int, r EvictionReason) {
+		reason = r
+	})
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Delete("a")
+	if reason != Deleted {
+		t.Error("expected reason deleted, got:", reason)
+	}
+}
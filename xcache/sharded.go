@@ -0,0 +1,190 @@
+package xcache
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+	"runtime"
+	"time"
+)
+
+// ShardedCache spreads its items over independently locked segments chosen by
+// key hash, trading a little memory for less lock contention under high
+// concurrency. It exposes the same API as Cache
+type ShardedCache[K comparable, V any] struct {
+	*shardedCache[K, V]
+}
+
+type shardedCache[K comparable, V any] struct {
+	seed    maphash.Seed
+	shards  []*cache[K, V]
+	janitor *janitor
+}
+
+// NewSharded returns a cache split into n segments, n defaults to the number
+// of CPUs when less than one. Expiration, cleanup interval and options behave
+// as for NewCache. Capacity bounds are divided over the segments so their
+// caps add up to exactly the bound; as every segment holds at least one
+// entry, n is lowered to the smallest bound when that is less than n
+func NewSharded[K comparable, V any](n int, expiration, cleanupInterval time.Duration, opts ...Option) *ShardedCache[K, V] {
+	if n < 1 {
+		n = runtime.NumCPU()
+	}
+
+	o := newOptions(opts)
+	if o.maxEntries > 0 && o.maxEntries < n {
+		n = o.maxEntries
+	}
+	if o.maxCost > 0 && o.maxCost < int64(n) {
+		n = int(o.maxCost)
+	}
+
+	sc := &shardedCache[K, V]{
+		seed:   maphash.MakeSeed(),
+		shards: make([]*cache[K, V], n),
+	}
+	for i := range sc.shards {
+		so := *o
+		so.maxEntries = int(perShard(int64(o.maxEntries), n, i))
+		so.maxCost = perShard(o.maxCost, n, i)
+		sc.shards[i] = newCache[K, V](expiration, &so)
+	}
+	// same garbage collection trick as NewCache
+	SC := &ShardedCache[K, V]{sc}
+	if cleanupInterval > 0 {
+		sc.janitor = runJanitor(o.ctx, sc.deleteExpired, cleanupInterval)
+		runtime.SetFinalizer(SC, stopShardedJanitor[K, V])
+	}
+
+	return SC
+}
+
+// perShard returns the part of limit for shard i of n, spreading the
+// remainder over the first shards so the parts add up to limit
+func perShard(limit int64, n, i int) int64 {
+	if limit <= 0 {
+		return limit
+	}
+	part := limit / int64(n)
+	if int64(i) < limit%int64(n) {
+		part++
+	}
+	return part
+}
+
+func stopShardedJanitor[K comparable, V any](sc *ShardedCache[K, V]) {
+	sc.Close()
+}
+
+// Close stops the janitor and the background refreshes of all segments, see Cache.Close
+func (sc *shardedCache[K, V]) Close() {
+	if sc.janitor != nil {
+		sc.janitor.halt()
+	}
+	for _, c := range sc.shards {
+		c.Close()
+	}
+}
+
+func (sc *shardedCache[K, V]) shard(k K) *cache[K, V] {
+	var h uint64
+	switch v := any(k).(type) {
+	case string:
+		h = maphash.String(sc.seed, v)
+	case int:
+		h = sc.hashUint(uint64(v))
+	case int32:
+		h = sc.hashUint(uint64(v))
+	case int64:
+		h = sc.hashUint(uint64(v))
+	case uint:
+		h = sc.hashUint(uint64(v))
+	case uint32:
+		h = sc.hashUint(uint64(v))
+	case uint64:
+		h = sc.hashUint(v)
+	default:
+		h = maphash.String(sc.seed, fmt.Sprint(k))
+	}
+	return sc.shards[h%uint64(len(sc.shards))]
+}
+
+func (sc *shardedCache[K, V]) hashUint(v uint64) uint64 {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return maphash.Bytes(sc.seed, b[:])
+}
+
+// Set an item to the cache, see Cache.Set
+func (sc *shardedCache[K, V]) Set(k K, x V, d time.Duration) {
+	sc.shard(k).Set(k, x, d)
+}
+
+// SetWithCost adds an item with a custom weight, see Cache.SetWithCost
+func (sc *shardedCache[K, V]) SetWithCost(k K, x V, d time.Duration, cost int64) {
+	sc.shard(k).SetWithCost(k, x, d, cost)
+}
+
+// Get an item from the cache, see Cache.Get
+func (sc *shardedCache[K, V]) Get(k K) (V, bool) {
+	return sc.shard(k).Get(k)
+}
+
+// GetOrLoad returns the cached value or loads it, see Cache.GetOrLoad
+func (sc *shardedCache[K, V]) GetOrLoad(ctx context.Context, k K, loader func(ctx context.Context) (V, time.Duration, error)) (V, error) {
+	return sc.shard(k).GetOrLoad(ctx, k, loader)
+}
+
+// Delete an item from the cache, see Cache.Delete
+func (sc *shardedCache[K, V]) Delete(k K) {
+	sc.shard(k).Delete(k)
+}
+
+// Items copies all unexpired items of all segments into a new map
+func (sc *shardedCache[K, V]) Items() map[K]TypedItem[V] {
+	m := make(map[K]TypedItem[V])
+	for _, c := range sc.shards {
+		for k, v := range c.Items() {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// ItemCount returns the number of items in all segments, including expired
+// items which have not yet been cleaned up.
+func (sc *shardedCache[K, V]) ItemCount() int {
+	var n int
+	for _, c := range sc.shards {
+		n += c.ItemCount()
+	}
+	return n
+}
+
+// OnEvicted sets the eviction callback of all segments, see Cache.OnEvicted
+func (sc *shardedCache[K, V]) OnEvicted(f func(K, V)) {
+	for _, c := range sc.shards {
+		c.OnEvicted(f)
+	}
+}
+
+// OnEvictedWithReason sets the eviction callback of all segments, see Cache.OnEvictedWithReason
+func (sc *shardedCache[K, V]) OnEvictedWithReason(f func(K, V, EvictionReason)) {
+	for _, c := range sc.shards {
+		c.OnEvictedWithReason(f)
+	}
+}
+
+// SetLoader registers the background refresh loader of all segments, see Cache.SetLoader
+func (sc *shardedCache[K, V]) SetLoader(loader func(ctx context.Context, k K) (V, time.Duration, error)) {
+	for _, c := range sc.shards {
+		c.SetLoader(loader)
+	}
+}
+
+func (sc *shardedCache[K, V]) deleteExpired() {
+	for _, c := range sc.shards {
+		c.deleteExpired()
+	}
+}
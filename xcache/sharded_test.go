@@ -0,0 +1,197 @@
+package xcache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedCache(t *testing.T) {
+	tc := NewSharded[string, int](4, DefaultExpiration, 0)
+
+	for i := 0; i < 100; i++ {
+		tc.Set(strconv.Itoa(i), i, DefaultExpiration)
+	}
+	for i := 0; i < 100; i++ {
+		v, found := tc.Get(strconv.Itoa(i))
+		if !found || v != i {
+			t.Fatal("unexpected value for", i, v, found)
+		}
+	}
+	if n := len(tc.Items()); n != 100 {
+		t.Error("expected 100 items, got:", n)
+	}
+
+	var evicted string
+	tc.OnEvicted(func(k string, _ int) {
+		evicted = k
+	})
+	tc.Delete("42")
+	if _, found := tc.Get("42"); found {
+		t.Error("42 was found, but it should have been deleted")
+	}
+	if evicted != "42" {
+		t.Error("onEvicted was not called for 42, got:", evicted)
+	}
+	if n := tc.ItemCount(); n != 99 {
+		t.Error("expected 99 items, got:", n)
+	}
+}
+
+func TestShardedCacheIntKeys(t *testing.T) {
+	tc := NewSharded[int64, string](0, DefaultExpiration, 0)
+	tc.Set(1, "a", DefaultExpiration)
+	if v, found := tc.Get(1); !found || v != "a" {
+		t.Error("unexpected value for 1:", v, found)
+	}
+}
+
+func TestShardedCacheBounded(t *testing.T) {
+	tc := NewSharded[int, int](4, DefaultExpiration, 0, WithMaxEntries(40))
+	for i := 0; i < 1000; i++ {
+		tc.Set(i, i, DefaultExpiration)
+	}
+	// the segment caps add up to the limit
+	if n := tc.ItemCount(); n > 40 {
+		t.Error("expected at most 40 items, got:", n)
+	}
+}
+
+func TestShardedCacheBoundsAddUp(t *testing.T) {
+	for _, tt := range []struct {
+		n, maxEntries, shards int
+		maxCost               int64
+	}{
+		{n: 8, maxEntries: 2, shards: 2},
+		{n: 4, maxEntries: 10, shards: 4},
+		{n: 3, maxEntries: 100, shards: 3},
+		{n: 8, maxCost: 3, shards: 3},
+		{n: 4, maxEntries: 10, maxCost: 7, shards: 4},
+	} {
+		var opts []Option
+		if tt.maxEntries > 0 {
+			opts = append(opts, WithMaxEntries(tt.maxEntries))
+		}
+		if tt.maxCost > 0 {
+			opts = append(opts, WithMaxCost(tt.maxCost))
+		}
+		tc := NewSharded[int, int](tt.n, DefaultExpiration, 0, opts...)
+		if len(tc.shards) != tt.shards {
+			t.Errorf("expected %d shards for %+v, got: %d", tt.shards, tt, len(tc.shards))
+		}
+
+		var entries int
+		var cost int64
+		for _, c := range tc.shards {
+			if c.maxEntries < 0 || (tt.maxEntries > 0 && c.maxEntries == 0) {
+				t.Errorf("expected every shard to be bounded for %+v, got: %d", tt, c.maxEntries)
+			}
+			entries += c.maxEntries
+			cost += c.maxCost
+		}
+		if entries != tt.maxEntries || cost != tt.maxCost {
+			t.Errorf("expected the shard caps to add up to %+v, got: %d entries and %d cost", tt, entries, cost)
+		}
+
+		for i := 0; i < 1000; i++ {
+			tc.Set(i, i, DefaultExpiration)
+		}
+		if n := tc.ItemCount(); tt.maxEntries > 0 && n > tt.maxEntries {
+			t.Errorf("expected at most %d items, got: %d", tt.maxEntries, n)
+		}
+	}
+}
+
+func TestShardedCacheTimes(t *testing.T) {
+	tc := NewSharded[string, int](4, 20*time.Millisecond, time.Millisecond)
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("b", 2, NoExpiration)
+
+	<-time.After(30 * time.Millisecond)
+	if _, found := tc.Get("a"); found {
+		t.Error("Found a when it should have been automatically deleted")
+	}
+	if _, found := tc.Get("b"); !found {
+		t.Error("Did not find b even though it was set to never expire")
+	}
+}
+
+func BenchmarkShardedCacheGetManyConcurrentExpiring(b *testing.B) {
+	benchmarkShardedCacheGetManyConcurrent(b, 5*time.Minute)
+}
+
+func BenchmarkShardedCacheGetManyConcurrentNotExpiring(b *testing.B) {
+	benchmarkShardedCacheGetManyConcurrent(b, NoExpiration)
+}
+
+func benchmarkShardedCacheGetManyConcurrent(b *testing.B, exp time.Duration) {
+	b.StopTimer()
+	n := 10000
+	tsc := NewSharded[string, string](20, exp, 0)
+	keys := make([]string, n)
+	for i := 0; i < n; i++ {
+		k := "foo" + strconv.Itoa(i)
+		keys[i] = k
+		tsc.Set(k, "bar", DefaultExpiration)
+	}
+	each := b.N / n
+	wg := new(sync.WaitGroup)
+	wg.Add(n)
+	for _, v := range keys {
+		go func(k string) {
+			for j := 0; j < each; j++ {
+				tsc.Get(k)
+			}
+			wg.Done()
+		}(v)
+	}
+	b.StartTimer()
+	wg.Wait()
+}
+
+// the mix benchmarks compare the single lock cache against the sharded cache
+// under parallel load with the given percentage of reads versus writes
+type benchCache interface {
+	Set(k string, x string, d time.Duration)
+	Get(k string) (string, bool)
+}
+
+func BenchmarkCacheParallelMix(b *testing.B) {
+	for _, reads := range []int{90, 50, 10} {
+		b.Run(strconv.Itoa(reads)+"pctRead", func(b *testing.B) {
+			benchmarkParallelMix(b, NewCache[string, string](NoExpiration, 0), reads)
+		})
+	}
+}
+
+func BenchmarkShardedCacheParallelMix(b *testing.B) {
+	for _, reads := range []int{90, 50, 10} {
+		b.Run(strconv.Itoa(reads)+"pctRead", func(b *testing.B) {
+			benchmarkParallelMix(b, NewSharded[string, string](0, NoExpiration, 0), reads)
+		})
+	}
+}
+
+func benchmarkParallelMix(b *testing.B, tc benchCache, reads int) {
+	b.StopTimer()
+	n := 1024
+	keys := make([]string, n)
+	for i := 0; i < n; i++ {
+		keys[i] = "foo" + strconv.Itoa(i)
+		tc.Set(keys[i], "bar", DefaultExpiration)
+	}
+	b.StartTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			k := keys[i%n]
+			if i%100 < reads {
+				tc.Get(k)
+			} else {
+				tc.Set(k, "bar", DefaultExpiration)
+			}
+			i++
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package xcache
+
+import "sync/atomic"
+
+// Stats is a point in time snapshot of the cache counters
+type Stats struct {
+	Hits    uint64
+	Misses  uint64
+	Sets    uint64
+	Deletes uint64
+	// Evictions counts the items which left the cache other than through Delete
+	Evictions map[EvictionReason]uint64
+	// Size is the number of items including expired ones not yet cleaned up
+	Size int
+}
+
+// HitRatio returns the share of lookups which found an item
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// StatsHook receives every counted cache event, which allows exporting them
+// to eg Prometheus collectors without this package depending on them. Hooks are
+// called synchronously, possibly while the cache lock is held, so they must be
+// cheap and must not call back into the cache
+type StatsHook interface {
+	Hit()
+	Miss()
+	Set()
+	Delete()
+	Evict(reason EvictionReason)
+}
+
+type stats struct {
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	sets      atomic.Uint64
+	deletes   atomic.Uint64
+	evictions [numEvictionReasons]atomic.Uint64
+	hook      StatsHook
+}
+
+func (s *stats) hit() {
+	s.hits.Add(1)
+	if s.hook != nil {
+		s.hook.Hit()
+	}
+}
+
+func (s *stats) miss() {
+	s.misses.Add(1)
+	if s.hook != nil {
+		s.hook.Miss()
+	}
+}
+
+func (s *stats) set() {
+	s.sets.Add(1)
+	if s.hook != nil {
+		s.hook.Set()
+	}
+}
+
+func (s *stats) evicted(reason EvictionReason) {
+	if reason == Deleted {
+		s.deletes.Add(1)
+		if s.hook != nil {
+			s.hook.Delete()
+		}
+		return
+	}
+	s.evictions[reason].Add(1)
+	if s.hook != nil {
+		s.hook.Evict(reason)
+	}
+}
+
+// add accumulates the counters into snapshot
+func (s *stats) add(snapshot *Stats) {
+	snapshot.Hits += s.hits.Load()
+	snapshot.Misses += s.misses.Load()
+	snapshot.Sets += s.sets.Load()
+	snapshot.Deletes += s.deletes.Load()
+	if snapshot.Evictions == nil {
+		snapshot.Evictions = make(map[EvictionReason]uint64)
+	}
+	for reason := range s.evictions {
+		if reason == int(Deleted) {
+			continue
+		}
+		snapshot.Evictions[EvictionReason(reason)] += s.evictions[reason].Load()
+	}
+}
+
+// Stats returns a snapshot of the cache counters
+func (c *cache[K, V]) Stats() Stats {
+	var s Stats
+	c.stats.add(&s)
+	s.Size = c.ItemCount()
+	return s
+}
+
+// Stats returns the counters summed over all segments
+func (sc *shardedCache[K, V]) Stats() Stats {
+	var s Stats
+	for _, c := range sc.shards {
+		c.stats.add(&s)
+		s.Size += c.ItemCount()
+	}
+	return s
+}
@@ -0,0 +1,79 @@
+package xcache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type countingHook struct {
+	mu     sync.Mutex
+	events map[string]int
+}
+
+func (h *countingHook) inc(event string) {
+	h.mu.Lock()
+	if h.events == nil {
+		h.events = make(map[string]int)
+	}
+	h.events[event]++
+	h.mu.Unlock()
+}
+
+func (h *countingHook) Hit()                        { h.inc("hit") }
+func (h *countingHook) Miss()                       { h.inc("miss") }
+func (h *countingHook) Set()                        { h.inc("set") }
+func (h *countingHook) Delete()                     { h.inc("delete") }
+func (h *countingHook) Evict(reason EvictionReason) { h.inc(reason.String()) }
+
+func TestStats(t *testing.T) {
+	hook := new(countingHook)
+	tc := NewCache[string, int](DefaultExpiration, 0, WithMaxEntries(2), WithStatsHook(hook))
+
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("b", 2, DefaultExpiration)
+	tc.Get("b")
+	tc.Get("x")
+	tc.Set("c", 3, DefaultExpiration)
+	tc.Delete("c")
+	tc.Set("d", 4, time.Millisecond)
+	<-time.After(2 * time.Millisecond)
+	tc.Get("d")
+	tc.deleteExpired()
+
+	s := tc.Stats()
+	if s.Hits != 1 || s.Misses != 2 {
+		t.Error("unexpected hits/misses:", s.Hits, s.Misses)
+	}
+	if s.Sets != 4 || s.Deletes != 1 {
+		t.Error("unexpected sets/deletes:", s.Sets, s.Deletes)
+	}
+	if s.Evictions[Capacity] != 1 || s.Evictions[Expired] != 1 {
+		t.Error("expected one capacity and one expired eviction, got:", s.Evictions)
+	}
+	if s.Size != 1 {
+		t.Error("expected a single item, got size:", s.Size)
+	}
+	if r := s.HitRatio(); r < 0.33 || r > 0.34 {
+		t.Error("unexpected hit ratio:", r)
+	}
+
+	want := map[string]int{"hit": 1, "miss": 2, "set": 4, "delete": 1, "capacity": 1, "expired": 1}
+	for event, n := range want {
+		if hook.events[event] != n {
+			t.Errorf("expected hook to receive %d %s events, got %d", n, event, hook.events[event])
+		}
+	}
+}
+
+func TestShardedStats(t *testing.T) {
+	tc := NewSharded[int, int](4, DefaultExpiration, 0)
+	for i := 0; i < 10; i++ {
+		tc.Set(i, i, DefaultExpiration)
+		tc.Get(i)
+	}
+	s := tc.Stats()
+	if s.Sets != 10 || s.Hits != 10 || s.Size != 10 {
+		t.Error("unexpected sharded stats:", s)
+	}
+}
@@ -25,14 +25,18 @@ func (cli *Client) retry(i int) bool {
 	return true
 }
 
-// performs exponential backoff based on attempts and limited by waitMax
 func (cli *Client) backoff(attempts int) time.Duration {
+	return Backoff(attempts, cli.config.WaitMin, cli.config.WaitMax)
+}
+
+// Backoff performs exponential backoff based on attempts starting at waitMin and limited by waitMax
+func Backoff(attempts int, waitMin, waitMax time.Duration) time.Duration {
 
-	mul := math.Pow(2, float64(attempts)) * float64(cli.config.WaitMin)
+	mul := math.Pow(2, float64(attempts)) * float64(waitMin)
 	sleep := time.Duration(mul)
 
-	if sleep > cli.config.WaitMax {
-		sleep = cli.config.WaitMax
+	if sleep > waitMax {
+		sleep = waitMax
 	}
 
 	return sleep
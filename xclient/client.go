@@ -28,13 +28,13 @@ type Client struct {
 // Config defines the config properties of the package
 type Config struct {
 	CustomHeader      map[string]string
-	ContentFormat     string
+	ContentFormat     string `default:"application/json"`
 	TrackProgress     bool
-	RecycleConnection bool
+	RecycleConnection bool          `default:"true"`
 	Limiter           *rate.Limiter // nil here will use default rate limit
-	MaxRetry          int
-	WaitMin           time.Duration
-	WaitMax           time.Duration
+	MaxRetry          int           `default:"5" validate:"min=0"`
+	WaitMin           time.Duration `default:"500ms" validate:"min=0s"`
+	WaitMax           time.Duration `default:"2s" validate:"min=0s"`
 	UseJsoniter       bool
 }
 
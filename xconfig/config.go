@@ -5,20 +5,115 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"reflect"
 
 	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
 )
 
-// LoadConfig reads in a toml file and inits the ServiceConfig
-func LoadConfig(filePath string, cfg any) error {
+// LoadConfig reads in a toml, yaml or json file and inits the ServiceConfig.
+// Fields missing from the file get the value of their default tag, references
+// like ${env:REDIS_PASS} are expanded and the result is checked against the
+// validate tags, see SetDefaults, ExpandSecrets and Validate. Nil struct
+// pointers allocated by the file get their defaults once it is decoded, so
+// within them a zero value in the file does not override a default. Further
+// files to layer on top are given by WithFiles
+func LoadConfig(filePath string, cfg any, opts ...Option) error {
+	o := newOptions(opts)
+	return load(cfg, append([]string{filePath}, o.files...), o)
+}
+
+// LoadConfigs reads the files like LoadConfig and layers them in order onto
+// cfg, eg base.yaml, production.yaml and local.yaml. Every key present in a
+// later file overrides the value of earlier ones, including zero values like
+// false, maps are merged by key and slices are replaced. Use LoadConfig with
+// WithFiles to pass options
+func LoadConfigs(cfg any, paths ...string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("no config files given")
+	}
+	return LoadConfig(paths[0], cfg, WithFiles(paths[1:]...))
+}
+
+// MustConfig load config and panic if fails. With WithFlags it serves
+// command line tools as well, the precedence being file < env < flags
+func MustConfig(filePath string, cfg any, opts ...Option) {
+	err := LoadConfig(filePath, cfg, opts...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func load(cfg any, paths []string, o *options) error {
+	// tags only apply to structs, other targets like maps are decoded as is
+	tagged := isStructPointer(cfg)
+	if !tagged {
+		// sources are tracked per struct field
+		o.sources = nil
+	} else if err := o.sources.track(cfg, "default", func() error { return SetDefaults(cfg) }); err != nil {
+		return err
+	}
+	// struct pointers allocated by the files, env or flags get their defaults afterwards
+	nils := make(map[string]bool)
+	if tagged {
+		nilStructs(reflect.ValueOf(cfg).Elem(), "", nils)
+	}
+
+	for _, filePath := range paths {
+		// every file is decoded into cfg, which only sets the keys present in it
+		err := o.sources.track(cfg, filePath, func() error {
+			return decode(filePath, cfg, o.strict)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.env {
+		if !tagged {
+			return LoadEnv(o.prefix, cfg)
+		}
+		if _, err := loadEnv(reflect.ValueOf(cfg).Elem(), o.prefix, "", o.sources); err != nil {
+			return err
+		}
+	}
+
+	if o.flags != nil && tagged {
+		if err := loadFlags(reflect.ValueOf(cfg).Elem(), o.flags, o.sources); err != nil {
+			return err
+		}
+	}
+
+	if tagged && len(nils) > 0 {
+		err := o.sources.track(cfg, "default", func() error {
+			return setAllocatedDefaults(reflect.ValueOf(cfg).Elem(), "", nils)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if tagged {
+		if err := expand(reflect.ValueOf(cfg).Elem(), "", o.resolvers); err != nil {
+			return err
+		}
+		return Validate(cfg)
+	}
+
+	return nil
+}
+
+// decode parses the file into cfg according to its extension, in strict mode
+// unknown keys and unsupported extensions are errors
+func decode(filePath string, cfg any, strict bool) error {
 	bytes, err := os.ReadFile(filePath)
 
 	if err != nil {
 		return fmt.Errorf("unable to read file %s: %w", filePath, err)
 	}
 
-	switch path.Ext(filePath) {
+	ext := path.Ext(filePath)
+	switch ext {
 	case ".toml":
 		err = toml.Unmarshal(bytes, cfg)
 	case ".yaml":
@@ -27,19 +122,19 @@ func LoadConfig(filePath string, cfg any) error {
 		err = yaml.Unmarshal(bytes, cfg)
 	case ".json":
 		err = json.Unmarshal(bytes, cfg)
+	default:
+		if strict {
+			return fmt.Errorf("unsupported config file extension %q of %s", ext, filePath)
+		}
 	}
 
 	if err != nil {
 		return fmt.Errorf("error while parsing config file %s: %w", string(bytes), err)
 	}
 
-	return nil
-}
-
-// MustConfig load config and panic if fails
-func MustConfig(filePath string, cfg any) {
-	err := LoadConfig(filePath, cfg)
-	if err != nil {
-		panic(err)
+	if strict {
+		return checkKeys(filePath, ext, bytes, cfg)
 	}
+
+	return nil
 }
@@ -0,0 +1,113 @@
+package xconfig
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SetDefaults sets every zero field of cfg, which must be a pointer to a
+// struct, to the value of its default tag, eg default:"5s" for a
+// time.Duration or default:"a,b" for a slice. Nested structs are walked,
+// nil struct pointers are left alone
+func SetDefaults(cfg any) error {
+	if !isStructPointer(cfg) {
+		return fmt.Errorf("config must be a pointer to a struct, got %T", cfg)
+	}
+	v := reflect.ValueOf(cfg)
+	return setDefaults(v.Elem(), "")
+}
+
+func setDefaults(v reflect.Value, path string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		fpath := join(path, field.Name)
+
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if !fv.IsZero() {
+				continue
+			}
+			if err := setValue(fv, def); err != nil {
+				return fmt.Errorf("invalid default for %s: %w", fpath, err)
+			}
+			continue
+		}
+
+		switch {
+		case isStruct(field.Type):
+			if err := setDefaults(fv, fpath); err != nil {
+				return err
+			}
+		case field.Type.Kind() == reflect.Pointer && isStruct(field.Type.Elem()) && !fv.IsNil():
+			if err := setDefaults(fv.Elem(), fpath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// nilStructs collects the paths of the nil struct pointers in the struct v
+func nilStructs(v reflect.Value, path string, out map[string]bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		fpath := join(path, field.Name)
+		switch {
+		case isStruct(field.Type):
+			nilStructs(fv, fpath, out)
+		case field.Type.Kind() == reflect.Pointer && isStruct(field.Type.Elem()):
+			if fv.IsNil() {
+				out[fpath] = true
+			} else {
+				nilStructs(fv.Elem(), fpath, out)
+			}
+		}
+	}
+}
+
+// setAllocatedDefaults sets the defaults of the struct pointers in v which
+// were nil at the paths in nils when SetDefaults ran, but have been allocated
+// since, eg by decoding a file setting one of their fields
+func setAllocatedDefaults(v reflect.Value, path string, nils map[string]bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		fpath := join(path, field.Name)
+		switch {
+		case isStruct(field.Type):
+			if err := setAllocatedDefaults(fv, fpath, nils); err != nil {
+				return err
+			}
+		case field.Type.Kind() == reflect.Pointer && isStruct(field.Type.Elem()) && !fv.IsNil():
+			if nils[fpath] {
+				if err := setDefaults(fv.Elem(), fpath); err != nil {
+					return err
+				}
+			} else if err := setAllocatedDefaults(fv.Elem(), fpath, nils); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// join appends name to the field path
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
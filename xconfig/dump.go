@@ -0,0 +1,156 @@
+package xconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mask replaces the values of fields tagged secret:"true" in a dump
+const Mask = "******"
+
+// Dump renders cfg, which must be a pointer to a struct, as "yaml" or "json"
+// with the values of fields tagged secret:"true" masked. With the optional
+// sources recorded by WithSources, the yaml is annotated with a comment on
+// where each value came from and the json is wrapped as {"config", "sources"}
+func Dump(cfg any, format string, sources Sources) ([]byte, error) {
+	if !isStructPointer(cfg) {
+		return nil, fmt.Errorf("config must be a pointer to a struct, got %T", cfg)
+	}
+	redacted := redact(reflect.ValueOf(cfg).Elem()).Interface()
+
+	switch format {
+	case "yaml", "yml":
+		var node yaml.Node
+		if err := node.Encode(redacted); err != nil {
+			return nil, fmt.Errorf("unable to encode config: %w", err)
+		}
+		annotate(&node, reflect.TypeOf(redacted), "", sources)
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); err != nil {
+			return nil, fmt.Errorf("unable to encode config: %w", err)
+		}
+		return buf.Bytes(), nil
+	case "json":
+		var v any = redacted
+		if len(sources) > 0 {
+			v = struct {
+				Config  any     `json:"config"`
+				Sources Sources `json:"sources"`
+			}{redacted, sources}
+		}
+		return json.MarshalIndent(v, "", " ")
+	default:
+		return nil, fmt.Errorf("unsupported dump format %q", format)
+	}
+}
+
+// redact returns a deep copy of v with secret fields masked, or zeroed if they are not strings
+func redact(v reflect.Value) reflect.Value {
+	out := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Struct:
+		out.Set(v)
+		if !isStruct(v.Type()) {
+			return out
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fv := out.Field(i)
+			if field.Tag.Get("secret") == "true" {
+				if fv.IsZero() {
+					continue
+				}
+				if fv.Kind() == reflect.String {
+					fv.SetString(Mask)
+				} else {
+					fv.SetZero()
+				}
+				continue
+			}
+			fv.Set(redact(v.Field(i)))
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			return out
+		}
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(redact(v.Elem()))
+		out.Set(p)
+	case reflect.Slice:
+		if v.IsNil() {
+			return out
+		}
+		out.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(redact(v.Index(i)))
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return out
+		}
+		out.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), redact(iter.Value()))
+		}
+	default:
+		out.Set(v)
+	}
+	return out
+}
+
+// annotate adds the source of each field of the struct type t as line comment to node
+func annotate(node *yaml.Node, t reflect.Type, path string, sources Sources) {
+	if len(sources) == 0 {
+		return
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if node.Kind != yaml.MappingNode || !isStruct(t) {
+		return
+	}
+
+	keys := make(map[string]int, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys[node.Content[i].Value] = i
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, inline := yamlKey(field)
+		fpath := join(path, field.Name)
+		if inline {
+			annotate(node, field.Type, fpath, sources)
+			continue
+		}
+		idx, ok := keys[name]
+		if !ok {
+			continue
+		}
+		key, value := node.Content[idx], node.Content[idx+1]
+		if source, ok := sources[fpath]; ok {
+			key.LineComment = "from " + source
+			if value.Kind == yaml.ScalarNode {
+				key.LineComment, value.LineComment = "", "from "+source
+			}
+			continue
+		}
+		annotate(value, field.Type, fpath, sources)
+	}
+}
@@ -0,0 +1,121 @@
+package xconfig
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// LoadEnv overlays environment variables onto cfg, which must be a pointer to
+// a struct. A field is read from the variable named by its env tag, or by its
+// name in upper snake case, prefixed by the names of its parent structs and
+// prefix, eg APP_REDIS_CONN_TIMEOUT for Redis.ConnTimeout. Fields tagged
+// env:"-" are skipped. Unset variables leave the field untouched
+func LoadEnv(prefix string, cfg any) error {
+	if !isStructPointer(cfg) {
+		return fmt.Errorf("config must be a pointer to a struct, got %T", cfg)
+	}
+	v := reflect.ValueOf(cfg)
+	_, err := loadEnv(v.Elem(), prefix, "", nil)
+	return err
+}
+
+// loadEnv walks the fields of the struct v at path and reports whether any
+// was set. The variable setting a field is recorded in the optional sources
+func loadEnv(v reflect.Value, prefix, path string, sources Sources) (bool, error) {
+	var set bool
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := envName(field)
+		if name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		fv := v.Field(i)
+		fpath := join(path, field.Name)
+		switch {
+		case isStruct(field.Type):
+			ok, err := loadEnv(fv, name, fpath, sources)
+			if err != nil {
+				return false, err
+			}
+			set = set || ok
+		case field.Type.Kind() == reflect.Pointer && isStruct(field.Type.Elem()):
+			// a nil struct is only allocated when one of its fields is set.
+			// Not descending without a matching variable also ends the walk
+			// of self-referential types such as type Node struct{ Next *Node }
+			if !hasEnvPrefix(name + "_") {
+				continue
+			}
+			p := fv
+			if fv.IsNil() {
+				p = reflect.New(field.Type.Elem())
+			}
+			ok, err := loadEnv(p.Elem(), name, fpath, sources)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				fv.Set(p)
+				set = true
+			}
+		default:
+			s, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+			if err := setValue(fv, s); err != nil {
+				return false, fmt.Errorf("unable to set %s from env %s: %w", fpath, name, err)
+			}
+			if sources != nil {
+				sources[fpath] = "env " + name
+			}
+			set = true
+		}
+	}
+	return set, nil
+}
+
+// hasEnvPrefix reports whether any environment variable starts with prefix
+func hasEnvPrefix(prefix string) bool {
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func envName(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("env"); ok && tag != "" {
+		return tag
+	}
+	return upperSnake(field.Name)
+}
+
+// upperSnake converts a go identifier to upper snake case, keeping
+// acronyms together, eg ConnTimeout to CONN_TIMEOUT and HTTPPort to HTTP_PORT
+func upperSnake(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,154 @@
+package xconfig
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldFlag is the flag.Value of a config field. It only validates and keeps
+// the argument, which is applied by LoadConfig after the file and env
+type fieldFlag struct {
+	path  string
+	typ   reflect.Type
+	value string
+}
+
+func (f *fieldFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *fieldFlag) Set(s string) error {
+	if err := setValue(reflect.New(f.typ).Elem(), s); err != nil {
+		return err
+	}
+	f.value = s
+	return nil
+}
+
+// Type names the type of the flag, which makes it a pflag.Value as well
+func (f *fieldFlag) Type() string {
+	return f.typ.String()
+}
+
+// IsBoolFlag allows boolean flags without argument, eg -debug
+func (f *fieldFlag) IsBoolFlag() bool {
+	return f.typ.Kind() == reflect.Bool
+}
+
+// BindFlags defines a flag on fs for every field of cfg, which must be a
+// pointer to a struct, tagged with flag:"name". The usage tag holds the help
+// text and the default tag the default shown. The flags are applied by
+// LoadConfig with WithFlags(fs), taking precedence over the file and env
+func BindFlags(fs *flag.FlagSet, cfg any) error {
+	if !isStructPointer(cfg) {
+		return fmt.Errorf("config must be a pointer to a struct, got %T", cfg)
+	}
+	return bindFlags(fs, reflect.TypeOf(cfg).Elem(), "", make(map[reflect.Type]bool))
+}
+
+// NewFlagSet returns a flag set named name with the flags of cfg, see BindFlags
+func NewFlagSet(name string, cfg any, handling flag.ErrorHandling) (*flag.FlagSet, error) {
+	fs := flag.NewFlagSet(name, handling)
+	if err := BindFlags(fs, cfg); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// bindFlags defines the flags of the struct type t, skipping pointers to the
+// structs in walk already, which would recurse forever for types like
+// type Node struct{ Next *Node }
+func bindFlags(fs *flag.FlagSet, t reflect.Type, path string, walk map[reflect.Type]bool) error {
+	walk[t] = true
+	defer delete(walk, t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fpath := join(path, field.Name)
+
+		name, ok := field.Tag.Lookup("flag")
+		if !ok || name == "-" {
+			switch {
+			case isStruct(field.Type):
+				if err := bindFlags(fs, field.Type, fpath, walk); err != nil {
+					return err
+				}
+			case field.Type.Kind() == reflect.Pointer && isStruct(field.Type.Elem()) && !walk[field.Type.Elem()]:
+				if err := bindFlags(fs, field.Type.Elem(), fpath, walk); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if isStruct(field.Type) {
+			return fmt.Errorf("flag %s is bound to the struct %s", name, fpath)
+		}
+		if fs.Lookup(name) != nil {
+			return fmt.Errorf("flag %s of %s is already defined", name, fpath)
+		}
+
+		f := &fieldFlag{path: fpath, typ: field.Type}
+		fs.Var(f, name, field.Tag.Get("usage"))
+		// only shown in the usage, the default tag itself is applied by SetDefaults
+		fs.Lookup(name).DefValue = field.Tag.Get("default")
+	}
+	return nil
+}
+
+// WithFlags applies the flags of fs defined by BindFlags which were set on
+// the command line. They override the file and the env
+func WithFlags(fs *flag.FlagSet) Option {
+	return func(o *options) {
+		o.flags = fs
+	}
+}
+
+// loadFlags sets the fields of the struct v from the flags set on fs
+func loadFlags(v reflect.Value, fs *flag.FlagSet, sources Sources) error {
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		ff, ok := f.Value.(*fieldFlag)
+		if !ok || err != nil {
+			return
+		}
+		fv, ferr := fieldByPath(v, ff.path)
+		if ferr == nil {
+			ferr = setValue(fv, ff.value)
+		}
+		if ferr != nil {
+			err = fmt.Errorf("unable to set %s from flag -%s: %w", ff.path, f.Name, ferr)
+			return
+		}
+		if sources != nil {
+			sources[ff.path] = "flag -" + f.Name
+		}
+	})
+	return err
+}
+
+// fieldByPath returns the field at path like Redis.Host, allocating nil struct pointers on the way
+func fieldByPath(v reflect.Value, path string) (reflect.Value, error) {
+	for _, name := range strings.Split(path, ".") {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("no field %s in %s", name, v.Type())
+		}
+		v = v.FieldByName(name)
+		if !v.IsValid() {
+			return reflect.Value{}, fmt.Errorf("no field %s", path)
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,50 @@
+package xconfig
+
+import "flag"
+
+// Option configures how a config is loaded
+type Option func(*options)
+
+type options struct {
+	env       bool
+	prefix    string
+	resolvers map[string]Resolver
+	sources   Sources
+	strict    bool
+	flags     *flag.FlagSet
+	files     []string
+}
+
+// WithEnv overlays environment variables onto the config after the file is
+// parsed. Variables are named after the prefix and the field path in upper
+// snake case, eg APP_REDIS_HOST for the field Redis.Host and prefix APP
+func WithEnv(prefix string) Option {
+	return func(o *options) {
+		o.env = true
+		o.prefix = prefix
+	}
+}
+
+// WithSources records in sources where each field of the config got its
+// value from while loading, see Sources
+func WithSources(sources Sources) Option {
+	return func(o *options) {
+		o.sources = sources
+	}
+}
+
+// WithFiles layers the files in order on top of the one given to LoadConfig,
+// see LoadConfigs. The env and flags override all files
+func WithFiles(paths ...string) Option {
+	return func(o *options) {
+		o.files = append(o.files, paths...)
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{resolvers: defaultResolvers()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
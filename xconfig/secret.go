@@ -0,0 +1,136 @@
+package xconfig
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Resolver looks up the value of a reference like ${scheme:ref} in config strings
+type Resolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// ResolverFunc adapts a function to a Resolver
+type ResolverFunc func(ref string) (string, error)
+
+// Resolve calls f(ref)
+func (f ResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+// WithResolver registers r for references like ${scheme:ref}, eg a secret
+// store. The env and file schemes are registered by default and can be replaced
+func WithResolver(scheme string, r Resolver) Option {
+	return func(o *options) {
+		o.resolvers[scheme] = r
+	}
+}
+
+var reference = regexp.MustCompile(`\$\{([A-Za-z][A-Za-z0-9_-]*):([^}]*)\}`)
+
+// defaultResolvers expand ${env:NAME} to the environment variable NAME and
+// ${file:/path} to the contents of the file without its trailing newline
+func defaultResolvers() map[string]Resolver {
+	return map[string]Resolver{
+		"env": ResolverFunc(func(ref string) (string, error) {
+			s, ok := os.LookupEnv(ref)
+			if !ok {
+				return "", fmt.Errorf("env %s is not set", ref)
+			}
+			return s, nil
+		}),
+		"file": ResolverFunc(func(ref string) (string, error) {
+			b, err := os.ReadFile(ref)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimRight(string(b), "\r\n"), nil
+		}),
+	}
+}
+
+// ExpandSecrets replaces the references like ${env:REDIS_PASS} in every
+// string field of cfg, which must be a pointer to a struct, by the value of
+// the resolver registered for their scheme. A reference may be part of a
+// longer string, eg redis://:${env:REDIS_PASS}@localhost. References with a
+// scheme no resolver is registered for, such as the shell style ${PORT:-8080},
+// are kept as is. Only WithResolver options are applied
+func ExpandSecrets(cfg any, opts ...Option) error {
+	if !isStructPointer(cfg) {
+		return fmt.Errorf("config must be a pointer to a struct, got %T", cfg)
+	}
+	return expand(reflect.ValueOf(cfg).Elem(), "", newOptions(opts).resolvers)
+}
+
+func expand(v reflect.Value, path string, resolvers map[string]Resolver) error {
+	switch v.Kind() {
+	case reflect.String:
+		s, err := resolve(v.String(), resolvers)
+		if err != nil {
+			return fmt.Errorf("unable to expand %s: %w", path, err)
+		}
+		v.SetString(s)
+	case reflect.Struct:
+		if !isStruct(v.Type()) {
+			return nil
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			if err := expand(v.Field(i), join(path, t.Field(i).Name), resolvers); err != nil {
+				return err
+			}
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return expand(v.Elem(), path, resolvers)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := expand(v.Index(i), fmt.Sprintf("%s[%d]", path, i), resolvers); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// map values are not addressable, so expand a copy and store it back
+			e := reflect.New(iter.Value().Type()).Elem()
+			e.Set(iter.Value())
+			if err := expand(e, fmt.Sprintf("%s[%v]", path, iter.Key()), resolvers); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), e)
+		}
+	}
+	return nil
+}
+
+// resolve replaces all references in s
+func resolve(s string, resolvers map[string]Resolver) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var err error
+	out := reference.ReplaceAllStringFunc(s, func(match string) string {
+		if err != nil {
+			return match
+		}
+		m := reference.FindStringSubmatch(match)
+		r, ok := resolvers[m[1]]
+		if !ok {
+			return match
+		}
+		var value string
+		if value, err = r.Resolve(m[2]); err != nil {
+			err = fmt.Errorf("resolving %s: %w", match, err)
+		}
+		return value
+	})
+	return out, err
+}
@@ -0,0 +1,75 @@
+package xconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Sources maps the path of each field, eg Redis.Host, to where its value was
+// loaded from: "default", the path of a config file, "env" and the name of
+// the variable or "flag" and the name of the flag. Fields keeping their zero
+// value are not listed
+type Sources map[string]string
+
+// track records source for every field whose value step changed
+func (s Sources) track(cfg any, source string, step func() error) error {
+	if s == nil {
+		return step()
+	}
+	before := make(map[string]string)
+	leaves(reflect.ValueOf(cfg).Elem(), "", before)
+	if err := step(); err != nil {
+		return err
+	}
+	after := make(map[string]string)
+	leaves(reflect.ValueOf(cfg).Elem(), "", after)
+	for path, value := range after {
+		if before[path] != value {
+			s[path] = source
+		}
+	}
+	return nil
+}
+
+// leaves fingerprints the value of every field of the struct v which is not a struct itself
+func leaves(v reflect.Value, path string, out map[string]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		fpath := join(path, field.Name)
+		switch {
+		case isStruct(field.Type):
+			leaves(fv, fpath, out)
+		case field.Type.Kind() == reflect.Pointer && isStruct(field.Type.Elem()) && !fv.IsNil():
+			leaves(fv.Elem(), fpath, out)
+		default:
+			// marshalling copies maps and slices which may be updated in place
+			b, err := json.Marshal(fv.Interface())
+			if err != nil {
+				b = []byte(fmt.Sprintf("%#v", fv.Interface()))
+			}
+			out[fpath] = string(b)
+		}
+	}
+}
+
+// String lists the sources sorted by field path, one per line
+func (s Sources) String() string {
+	paths := make([]string, 0, len(s))
+	for path := range s {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var b strings.Builder
+	for _, path := range paths {
+		fmt.Fprintf(&b, "%s: %s\n", path, s[path])
+	}
+	return b.String()
+}
@@ -0,0 +1,244 @@
+package xconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// WithStrict makes loading fail on keys which do not match a field of the
+// config, eg a misspelled pool_sise, and on unsupported file extensions
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// UnknownKey is a key of a config file which does not match any field
+type UnknownKey struct {
+	Key    string
+	Line   int
+	Column int
+}
+
+func (k UnknownKey) String() string {
+	if k.Line == 0 {
+		return k.Key
+	}
+	return fmt.Sprintf("%s (line %d, column %d)", k.Key, k.Line, k.Column)
+}
+
+// UnknownKeysError lists the unknown keys found in a config file by strict mode
+type UnknownKeysError struct {
+	File string
+	Keys []UnknownKey
+}
+
+func (e *UnknownKeysError) Error() string {
+	keys := make([]string, len(e.Keys))
+	for i, k := range e.Keys {
+		keys[i] = k.String()
+	}
+	return fmt.Sprintf("unknown keys in config file %s: %s", e.File, strings.Join(keys, "; "))
+}
+
+var (
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// checkKeys returns an *UnknownKeysError if the file contents have keys cfg has no field for
+func checkKeys(filePath, ext string, bytes []byte, cfg any) error {
+	var keys []UnknownKey
+	switch ext {
+	case ".toml":
+		md, err := toml.Decode(string(bytes), reflect.New(reflect.TypeOf(cfg).Elem()).Interface())
+		if err != nil {
+			return fmt.Errorf("error while parsing config file %s: %w", filePath, err)
+		}
+		keys = tomlUnknownKeys(md.Undecoded(), strings.Split(string(bytes), "\n"))
+	case ".yaml", ".yml", ".json":
+		// json is valid yaml, which keeps track of the position of each key
+		var node yaml.Node
+		if err := yaml.Unmarshal(bytes, &node); err != nil {
+			return fmt.Errorf("error while parsing config file %s: %w", filePath, err)
+		}
+		key, fold := yamlKey, false
+		if ext == ".json" {
+			// unlike yaml.v3, encoding/json matches keys case insensitively
+			key, fold = jsonKey, true
+		}
+		unknownKeys(&node, reflect.TypeOf(cfg), "", key, fold, &keys)
+	}
+	if len(keys) > 0 {
+		return &UnknownKeysError{File: filePath, Keys: keys}
+	}
+	return nil
+}
+
+// unknownKeys walks node along the type t and collects the keys t has no
+// field for, ignoring the case of the keys if fold is set
+func unknownKeys(node *yaml.Node, t reflect.Type, path string, key func(reflect.StructField) (string, bool), fold bool, out *[]UnknownKey) {
+	if node.Kind == yaml.DocumentNode || node.Kind == yaml.AliasNode {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		} else if len(node.Content) > 0 {
+			node = node.Content[0]
+		}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	pt := reflect.PointerTo(t)
+	if pt.Implements(textUnmarshalerType) || pt.Implements(yamlUnmarshalerType) || pt.Implements(jsonUnmarshalerType) {
+		return
+	}
+
+	switch {
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := make(map[string]reflect.Type)
+		structFields(t, key, fold, fields)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			if k.Value == "<<" {
+				continue
+			}
+			kpath := join(path, k.Value)
+			ft, ok := fields[k.Value]
+			if !ok && fold {
+				ft, ok = fields[strings.ToLower(k.Value)]
+			}
+			if !ok {
+				*out = append(*out, UnknownKey{Key: kpath, Line: k.Line, Column: k.Column})
+				continue
+			}
+			unknownKeys(v, ft, kpath, key, fold, out)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			unknownKeys(node.Content[i+1], t.Elem(), join(path, node.Content[i].Value), key, fold, out)
+		}
+	case node.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for i, item := range node.Content {
+			unknownKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), key, fold, out)
+		}
+	}
+}
+
+// structFields collects the keys of the fields of t including inlined structs,
+// also in lower case if fold is set
+func structFields(t reflect.Type, key func(reflect.StructField) (string, bool), fold bool, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name, inline := key(field)
+		switch {
+		case name == "-":
+		case inline:
+			ft := field.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				structFields(ft, key, fold, fields)
+			}
+		default:
+			fields[name] = field.Type
+			if fold {
+				fields[strings.ToLower(name)] = field.Type
+			}
+		}
+	}
+}
+
+// yamlKey returns the key yaml.v3 decodes the field from and whether it is inlined
+func yamlKey(field reflect.StructField) (string, bool) {
+	name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	inline := false
+	for _, opt := range strings.Split(opts, ",") {
+		inline = inline || opt == "inline"
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, inline
+}
+
+// jsonKey returns the key encoding/json decodes the field from and whether it is inlined
+func jsonKey(field reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		if field.Anonymous {
+			return "", true
+		}
+		name = field.Name
+	}
+	return name, false
+}
+
+// tomlUnknownKeys locates the undecoded keys in the lines of the file, only
+// reporting the outermost of nested unknown keys
+func tomlUnknownKeys(undecoded []toml.Key, lines []string) []UnknownKey {
+	unknown := make(map[string]bool, len(undecoded))
+	for _, k := range undecoded {
+		unknown[k.String()] = true
+	}
+
+	var keys []UnknownKey
+next:
+	for _, k := range undecoded {
+		for i := 1; i < len(k); i++ {
+			if unknown[k[:i].String()] {
+				continue next
+			}
+		}
+		line, column := tomlPosition(k, lines)
+		keys = append(keys, UnknownKey{Key: k.String(), Line: line, Column: column})
+	}
+	return keys
+}
+
+// tomlPosition finds the line and column defining the key k, tracking the
+// current table of every line. It returns zeros when the key is not found
+func tomlPosition(k toml.Key, lines []string) (int, int) {
+	var table string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			header := strings.Trim(strings.SplitN(trimmed, "]", 2)[0], "[ ")
+			table = tomlUnquote(header)
+			if table == k.String() {
+				return i + 1, strings.Index(line, header) + 1
+			}
+			continue
+		}
+		name, _, ok := strings.Cut(trimmed, "=")
+		if !ok || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		full := tomlUnquote(name)
+		if table != "" {
+			full = table + "." + full
+		}
+		if full == k.String() {
+			return i + 1, strings.Index(line, name) + 1
+		}
+	}
+	return 0, 0
+}
+
+// tomlUnquote normalizes a dotted key like a . "b" to a.b
+func tomlUnquote(key string) string {
+	parts := strings.Split(key, ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(p), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/thisisdevelopment/go-dockly/v3/xconfig"
+	"github.com/thisisdevelopment/go-dockly/v3/xredis"
+)
+
+type DumpTest struct {
+	Name   string        `yaml:"name" json:"name"`
+	Level  string        `yaml:"level" json:"level" default:"info"`
+	APIKey string        `yaml:"api_key" json:"api_key" secret:"true"`
+	Redis  xredis.Config `yaml:"redis" json:"redis"`
+}
+
+func TestDump(t *testing.T) {
+	t.Setenv("APP_API_KEY", "key")
+	t.Setenv("APP_REDIS_DB", "2")
+
+	var cfg DumpTest
+	sources := make(xconfig.Sources)
+	err := xconfig.LoadConfig("dump.yaml", &cfg, xconfig.WithEnv("APP"), xconfig.WithSources(sources))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"Name":               "dump.yaml",
+		"Level":              "default",
+		"APIKey":             "env APP_API_KEY",
+		"Redis.Host":         "dump.yaml",
+		"Redis.Pass":         "dump.yaml",
+		"Redis.DB":           "env APP_REDIS_DB",
+		"Redis.ConnTimeOut":  "default",
+		"Redis.PollInterval": "default",
+	}
+	for path, source := range expected {
+		if sources[path] != source {
+			t.Errorf("expected %s from %s, got: %s", path, source, sources[path])
+		}
+	}
+	if len(sources) != len(expected) {
+		t.Error("expected only set fields to be tracked, got:", sources)
+	}
+
+	b, err := xconfig.Dump(&cfg, "yaml", sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for _, line := range []string{
+		"name: service # from dump.yaml",
+		"api_key: '******' # from env APP_API_KEY",
+		"host: localhost:6379 # from dump.yaml",
+		"db: 2 # from env APP_REDIS_DB",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected the dump to contain %q, got:\n%s", line, out)
+		}
+	}
+	if strings.Contains(out, "hunter2") || strings.Contains(out, ": key") {
+		t.Error("expected the secrets to be masked, got:\n", out)
+	}
+	if cfg.Redis.Pass != "hunter2" || cfg.APIKey != "key" {
+		t.Error("expected the config itself to be untouched, got:", cfg.Redis.Pass, cfg.APIKey)
+	}
+
+	b, err = xconfig.Dump(&cfg, "json", sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dumped struct {
+		Config  DumpTest
+		Sources map[string]string
+	}
+	if err = json.Unmarshal(b, &dumped); err != nil {
+		t.Fatal(err)
+	}
+	if dumped.Config.Redis.Pass != xconfig.Mask || dumped.Config.Name != "service" || dumped.Sources["Name"] != "dump.yaml" {
+		t.Error("expected the masked json dump with its sources, got:", string(b))
+	}
+
+	if _, err = xconfig.Dump(&cfg, "xml", nil); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thisisdevelopment/go-dockly/v3/xconfig"
+)
+
+type EnvRedis struct {
+	Host        string
+	ConnTimeout time.Duration
+	DB          int `env:"DATABASE"`
+}
+
+type EnvTest struct {
+	Test     string `yaml:"test"`
+	Redis    EnvRedis
+	Cache    *EnvRedis
+	Missing  *EnvRedis
+	Hosts    []string
+	Ports    []int
+	Weights  map[string]float64
+	Debug    bool
+	Ignored  string `env:"-"`
+	HTTPPort uint16
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("APP_TEST", "env")
+	t.Setenv("APP_REDIS_HOST", "redis:6379")
+	t.Setenv("APP_REDIS_CONN_TIMEOUT", "1m30s")
+	t.Setenv("APP_REDIS_DATABASE", "3")
+	t.Setenv("APP_CACHE_HOST", "cache")
+	t.Setenv("APP_HOSTS", "a, b,c")
+	t.Setenv("APP_PORTS", "80,443")
+	t.Setenv("APP_WEIGHTS", "a=0.5,b=2")
+	t.Setenv("APP_DEBUG", "true")
+	t.Setenv("APP_IGNORED", "set")
+	t.Setenv("APP_HTTP_PORT", "8080")
+
+	var cfg EnvTest
+	if err := xconfig.LoadConfig("cfg.yaml", &cfg, xconfig.WithEnv("APP")); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Test != "env" {
+		t.Error("expected the env to override the file, got:", cfg.Test)
+	}
+	if cfg.Redis.Host != "redis:6379" || cfg.Redis.ConnTimeout != 90*time.Second || cfg.Redis.DB != 3 {
+		t.Error("expected the nested struct to be set, got:", cfg.Redis)
+	}
+	if cfg.Cache == nil || cfg.Cache.Host != "cache" {
+		t.Error("expected the nested pointer to be allocated, got:", cfg.Cache)
+	}
+	if cfg.Missing != nil {
+		t.Error("expected a pointer without env vars to stay nil, got:", cfg.Missing)
+	}
+	if len(cfg.Hosts) != 3 || cfg.Hosts[1] != "b" || len(cfg.Ports) != 2 || cfg.Ports[1] != 443 {
+		t.Error("expected the slices to be set, got:", cfg.Hosts, cfg.Ports)
+	}
+	if cfg.Weights["a"] != 0.5 || cfg.Weights["b"] != 2 {
+		t.Error("expected the map to be set, got:", cfg.Weights)
+	}
+	if !cfg.Debug || cfg.HTTPPort != 8080 {
+		t.Error("expected the scalars to be set, got:", cfg.Debug, cfg.HTTPPort)
+	}
+	if cfg.Ignored != "" {
+		t.Error("expected the skipped field to be ignored, got:", cfg.Ignored)
+	}
+}
+
+func TestLoadEnvWithoutOption(t *testing.T) {
+	t.Setenv("TEST", "env")
+
+	var cfg YamlTest
+	if err := xconfig.LoadConfig("cfg.yaml", &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Test != "me" {
+		t.Error("expected the env to be ignored without WithEnv, got:", cfg.Test)
+	}
+}
+
+func TestLoadEnvInvalid(t *testing.T) {
+	t.Setenv("APP_REDIS_CONN_TIMEOUT", "soon")
+
+	var cfg EnvTest
+	if err := xconfig.LoadEnv("APP", &cfg); err == nil {
+		t.Error("expected an error for an invalid duration")
+	}
+	if err := xconfig.LoadEnv("APP", cfg); err == nil {
+		t.Error("expected an error for a non pointer config")
+	}
+}
+
+type EnvNode struct {
+	Name string
+	Next *EnvNode
+}
+
+func TestLoadEnvRecursive(t *testing.T) {
+	t.Setenv("APP_NAME", "first")
+	t.Setenv("APP_NEXT_NEXT_NAME", "third")
+
+	var cfg EnvNode
+	if err := xconfig.LoadEnv("APP", &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "first" || cfg.Next == nil || cfg.Next.Next == nil || cfg.Next.Next.Name != "third" {
+		t.Fatal("expected the nodes along the set variables to be allocated, got:", cfg)
+	}
+	if cfg.Next.Next.Next != nil {
+		t.Error("expected the last node to end the list, got:", cfg.Next.Next.Next)
+	}
+
+	// a node pointing to itself ends once no variable matches the prefix
+	cfg.Next.Next.Next = cfg.Next
+	if err := xconfig.LoadEnv("APP", &cfg); err != nil {
+		t.Fatal(err)
+	}
+}
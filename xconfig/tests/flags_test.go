@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thisisdevelopment/go-dockly/v3/xconfig"
+)
+
+type FlagRedis struct {
+	Host    string        `yaml:"host" flag:"redis-host" usage:"redis address"`
+	Timeout time.Duration `yaml:"timeout" flag:"redis-timeout" usage:"connect timeout" default:"5s"`
+}
+
+type FlagTest struct {
+	Name    string    `yaml:"name" flag:"name" usage:"service name"`
+	Level   string    `yaml:"level" flag:"level" usage:"log level" validate:"oneof=debug info"`
+	Debug   bool      `yaml:"debug" flag:"debug" usage:"enable debugging"`
+	Tags    []string  `yaml:"tags" flag:"tags" usage:"comma separated tags"`
+	Redis   FlagRedis `yaml:"redis"`
+	Ignored string    `yaml:"ignored"`
+}
+
+func TestFlags(t *testing.T) {
+	t.Setenv("APP_NAME", "env")
+	t.Setenv("APP_LEVEL", "info")
+
+	var cfg FlagTest
+	fs, err := xconfig.NewFlagSet("tool", &cfg, flag.ContinueOnError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.Parse([]string{"-level", "debug", "-debug", "-tags", "a,b", "-redis-timeout", "1s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != "" {
+		t.Error("expected the flags to be applied on load only, got:", cfg.Level)
+	}
+
+	sources := make(xconfig.Sources)
+	xconfig.MustConfig("flags.yaml", &cfg, xconfig.WithEnv("APP"), xconfig.WithFlags(fs), xconfig.WithSources(sources))
+
+	if cfg.Redis.Host != "file:6379" {
+		t.Error("expected the file value without env or flag, got:", cfg.Redis.Host)
+	}
+	if cfg.Name != "env" {
+		t.Error("expected the env to override the file, got:", cfg.Name)
+	}
+	if cfg.Level != "debug" || sources["Level"] != "flag -level" {
+		t.Error("expected the flag to override the env, got:", cfg.Level, sources["Level"])
+	}
+	if !cfg.Debug || len(cfg.Tags) != 2 || cfg.Redis.Timeout != time.Second {
+		t.Error("expected the flags to be set, got:", cfg)
+	}
+}
+
+func TestFlagsUsage(t *testing.T) {
+	var cfg FlagTest
+	fs, err := xconfig.NewFlagSet("tool", &cfg, flag.ContinueOnError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs.Lookup("ignored") != nil {
+		t.Error("expected untagged fields to have no flag")
+	}
+
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	fs.PrintDefaults()
+	for _, s := range []string{"-redis-host", "redis address", "connect timeout (default 5s)", "-debug"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("expected the usage to contain %q, got:\n%s", s, out.String())
+		}
+	}
+
+	if err = fs.Parse([]string{"-redis-timeout", "soon"}); err == nil {
+		t.Error("expected an invalid flag value to be rejected")
+	}
+}
+
+func TestBindFlagsDuplicate(t *testing.T) {
+	cfg := struct {
+		A string `flag:"name"`
+		B string `flag:"name"`
+	}{}
+	if err := xconfig.BindFlags(flag.NewFlagSet("tool", flag.ContinueOnError), &cfg); err == nil {
+		t.Error("expected an error for a duplicate flag")
+	}
+}
+
+type FlagNode struct {
+	Name string `flag:"name"`
+	Next *FlagNode
+}
+
+func TestBindFlagsRecursive(t *testing.T) {
+	fs := flag.NewFlagSet("tool", flag.ContinueOnError)
+	if err := xconfig.BindFlags(fs, new(FlagNode)); err != nil {
+		t.Fatal(err)
+	}
+	if fs.Lookup("name") == nil {
+		t.Error("expected the flag of the outer node to be defined")
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thisisdevelopment/go-dockly/v3/xconfig"
+)
+
+type LayerRedis struct {
+	Host        string        `yaml:"host" json:"host"`
+	PoolSize    int           `yaml:"pool_size" json:"pool_size"`
+	ConnTimeout time.Duration `yaml:"conn_timeout" json:"conn_timeout" default:"1s"`
+}
+
+type LayerTest struct {
+	Name   string         `yaml:"name" json:"name" validate:"required"`
+	Level  string         `yaml:"level" json:"level"`
+	Redis  LayerRedis     `yaml:"redis" json:"redis"`
+	Limits map[string]int `yaml:"limits" json:"limits"`
+	Debug  bool           `yaml:"debug" json:"debug" default:"true"`
+}
+
+func TestLoadConfigs(t *testing.T) {
+	var cfg LayerTest
+	err := xconfig.LoadConfigs(&cfg, "layers/base.yaml", "layers/production.json", "layers/local.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Name != "service" {
+		t.Error("expected values of the base to be kept, got:", cfg.Name)
+	}
+	if cfg.Level != "debug" {
+		t.Error("expected the last file to win, got:", cfg.Level)
+	}
+	if cfg.Redis.Host != "redis.prod:6379" || cfg.Redis.PoolSize != 2 || cfg.Redis.ConnTimeout != 5*time.Second {
+		t.Error("expected the nested struct to be deep merged, got:", cfg.Redis)
+	}
+	if cfg.Limits["read"] != 100 || cfg.Limits["write"] != 50 {
+		t.Error("expected the map to be deep merged, got:", cfg.Limits)
+	}
+}
+
+func TestLoadConfigsZeroValues(t *testing.T) {
+	var cfg LayerTest
+	if err := xconfig.LoadConfigs(&cfg, "layers/base.yaml", "layers/quiet.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Debug || cfg.Level != "" || cfg.Redis.PoolSize != 0 {
+		t.Error("expected the zero values of the later file to override, got:", cfg.Debug, cfg.Level, cfg.Redis.PoolSize)
+	}
+	if cfg.Redis.Host != "localhost:6379" || cfg.Limits["read"] != 100 {
+		t.Error("expected keys missing from the later file to be kept, got:", cfg.Redis, cfg.Limits)
+	}
+}
+
+func TestLoadConfigsWithEnv(t *testing.T) {
+	t.Setenv("APP_LEVEL", "warn")
+	t.Setenv("APP_REDIS_POOL_SIZE", "8")
+
+	var cfg LayerTest
+	sources := make(xconfig.Sources)
+	err := xconfig.LoadConfig("layers/base.yaml", &cfg,
+		xconfig.WithFiles("layers/production.json", "layers/local.yml"),
+		xconfig.WithEnv("APP"),
+		xconfig.WithSources(sources),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Level != "warn" || cfg.Redis.PoolSize != 8 {
+		t.Error("expected the env to override all files, got:", cfg.Level, cfg.Redis.PoolSize)
+	}
+	if cfg.Name != "service" || cfg.Redis.Host != "redis.prod:6379" {
+		t.Error("expected the merged files to be kept, got:", cfg.Name, cfg.Redis.Host)
+	}
+	if sources["Level"] != "env APP_LEVEL" || sources["Redis.Host"] != "layers/production.json" {
+		t.Error("expected the sources to name the env and the files, got:", sources)
+	}
+}
+
+func TestLoadConfigsErrors(t *testing.T) {
+	var cfg LayerTest
+	if err := xconfig.LoadConfigs(&cfg); err == nil {
+		t.Error("expected an error without files")
+	}
+	if err := xconfig.LoadConfigs(&cfg, "layers/base.yaml", "layers/missing.yaml"); err == nil {
+		t.Error("expected an error for a missing layer")
+	}
+	var local LayerTest
+	if err := xconfig.LoadConfigs(&local, "layers/local.yml"); err == nil {
+		t.Error("expected the merged config to be validated")
+	}
+}
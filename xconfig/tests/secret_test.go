@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/thisisdevelopment/go-dockly/v3/xconfig"
+	"github.com/thisisdevelopment/go-dockly/v3/xredis"
+)
+
+type SecretTest struct {
+	Redis   xredis.Config     `yaml:"redis"`
+	DSN     string            `yaml:"dsn"`
+	Token   string            `yaml:"token"`
+	Headers map[string]string `yaml:"headers"`
+	Plain   string            `yaml:"plain"`
+}
+
+// fakeVault stands in for a secret store
+type fakeVault map[string]string
+
+func (v fakeVault) Resolve(ref string) (string, error) {
+	s, ok := v[ref]
+	if !ok {
+		return "", errors.New("secret not found")
+	}
+	return s, nil
+}
+
+func TestSecrets(t *testing.T) {
+	t.Setenv("TEST_REDIS_PASS", "redis-pass")
+	vault := fakeVault{"db/password": "db-pass", "api/token": "api-token"}
+
+	var cfg SecretTest
+	if err := xconfig.LoadConfig("secret.yaml", &cfg, xconfig.WithResolver("vault", vault)); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Redis.Pass != "redis-pass" {
+		t.Error("expected the env reference to be expanded, got:", cfg.Redis.Pass)
+	}
+	if cfg.DSN != "postgres://app:db-pass@db:5432/app" {
+		t.Error("expected the embedded reference to be expanded, got:", cfg.DSN)
+	}
+	if cfg.Token != "s3cr3t" {
+		t.Error("expected the file reference to be expanded, got:", cfg.Token)
+	}
+	if cfg.Headers["authorization"] != "Bearer api-token" {
+		t.Error("expected the map value to be expanded, got:", cfg.Headers)
+	}
+	if cfg.Plain != "no $references {here}" {
+		t.Error("expected a string without references to be kept, got:", cfg.Plain)
+	}
+}
+
+func TestSecretsErrors(t *testing.T) {
+	t.Setenv("TEST_REDIS_PASS", "redis-pass")
+
+	var cfg SecretTest
+	err := xconfig.LoadConfig("secret.yaml", &cfg, xconfig.WithResolver("vault", fakeVault{}))
+	if err == nil {
+		t.Error("expected the resolver error to be returned")
+	}
+}
+
+func TestSecretsUnknownScheme(t *testing.T) {
+	t.Setenv("TEST_REDIS_PASS", "redis-pass")
+
+	var cfg SecretTest
+	if err := xconfig.LoadConfig("secret.yaml", &cfg); err != nil {
+		t.Fatal("expected references without a resolver to be kept, got:", err)
+	}
+	if cfg.DSN != "postgres://app:${vault:db/password}@db:5432/app" || cfg.Redis.Pass != "redis-pass" {
+		t.Error("expected only the registered schemes to be expanded, got:", cfg.DSN, cfg.Redis.Pass)
+	}
+
+	shell := SecretTest{Plain: "${PORT:-8080}", Token: "${env:TEST_REDIS_PASS}"}
+	if err := xconfig.ExpandSecrets(&shell); err != nil {
+		t.Fatal(err)
+	}
+	if shell.Plain != "${PORT:-8080}" || shell.Token != "redis-pass" {
+		t.Error("expected the shell style default to be kept, got:", shell.Plain, shell.Token)
+	}
+}
+
+func TestExpandSecrets(t *testing.T) {
+	cfg := SecretTest{Token: "${upper:token}", Headers: map[string]string{"a": "${upper:b}"}}
+	upper := xconfig.ResolverFunc(func(ref string) (string, error) {
+		return "<" + ref + ">", nil
+	})
+	if err := xconfig.ExpandSecrets(&cfg, xconfig.WithResolver("upper", upper)); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Token != "<token>" || cfg.Headers["a"] != "<b>" {
+		t.Error("expected the references to be expanded, got:", cfg.Token, cfg.Headers)
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/thisisdevelopment/go-dockly/v3/xconfig"
+)
+
+type StrictServer struct {
+	Name string `yaml:"name" json:"name" toml:"name"`
+	Port int    `yaml:"port" json:"port" toml:"port"`
+}
+
+type StrictRedis struct {
+	Host     string `yaml:"host" toml:"host"`
+	PoolSize int    `yaml:"pool_size" json:"pool_size" toml:"pool_size"`
+}
+
+type StrictTest struct {
+	Name    string            `yaml:"name" toml:"name"`
+	Redis   StrictRedis       `yaml:"redis" json:"redis" toml:"redis"`
+	Servers []StrictServer    `yaml:"servers" json:"servers" toml:"servers"`
+	Labels  map[string]string `yaml:"labels" json:"labels" toml:"labels"`
+}
+
+func TestStrict(t *testing.T) {
+	for file, expected := range map[string][]xconfig.UnknownKey{
+		"strict/cfg.yaml": {
+			{Key: "redis.pool_sise", Line: 4, Column: 3},
+			{Key: "servers[0].prot", Line: 7, Column: 5},
+		},
+		// yaml.v3 matches keys case sensitively, unlike encoding/json
+		"strict/case.yaml": {
+			{Key: "Name", Line: 1, Column: 1},
+			{Key: "redis.Host", Line: 3, Column: 3},
+			{Key: "redis.POOL_SIZE", Line: 4, Column: 3},
+		},
+		"strict/cfg.json": {
+			{Key: "redis.pool_sise", Line: 5, Column: 5},
+			{Key: "servers[0].prot", Line: 7, Column: 29},
+		},
+		"strict/cfg.toml": {
+			{Key: "redis.pool_sise", Line: 5, Column: 1},
+			{Key: "servers.prot", Line: 12, Column: 1},
+		},
+	} {
+		var cfg StrictTest
+		err := xconfig.LoadConfig(file, &cfg, xconfig.WithStrict())
+
+		var uerr *xconfig.UnknownKeysError
+		if !errors.As(err, &uerr) {
+			t.Errorf("expected unknown keys in %s, got: %v", file, err)
+			continue
+		}
+		if len(uerr.Keys) != len(expected) {
+			t.Errorf("expected %d unknown keys in %s, got: %v", len(expected), file, uerr)
+			continue
+		}
+		for i, key := range uerr.Keys {
+			if key != expected[i] {
+				t.Errorf("expected %v in %s, got: %v", expected[i], file, key)
+			}
+		}
+
+		if err = xconfig.LoadConfig(file, &cfg); err != nil {
+			t.Errorf("expected %s to load without strict mode, got: %v", file, err)
+		}
+	}
+}
+
+func TestStrictValid(t *testing.T) {
+	var cfg StrictTest
+	if err := xconfig.LoadConfig("strict/valid.toml", &cfg, xconfig.WithStrict()); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Redis.PoolSize != 10 {
+		t.Error("expected the config to be loaded, got:", cfg)
+	}
+	if err := xconfig.LoadConfig("cfg.yaml", &YamlTest{}, xconfig.WithStrict()); err != nil {
+		t.Error(err)
+	}
+	if err := xconfig.LoadConfig("cfg.json", &JsonTest{}, xconfig.WithStrict()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStrictExtension(t *testing.T) {
+	var cfg StrictTest
+	if err := xconfig.LoadConfig("strict/cfg.ini", &cfg, xconfig.WithStrict()); err == nil {
+		t.Error("expected an error for an unsupported extension")
+	}
+	if err := xconfig.LoadConfig("strict/cfg.ini", &cfg); err != nil {
+		t.Error("expected an unsupported extension to be ignored without strict mode, got:", err)
+	}
+}
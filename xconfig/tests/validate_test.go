@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thisisdevelopment/go-dockly/v3/xclient"
+	"github.com/thisisdevelopment/go-dockly/v3/xconfig"
+	"github.com/thisisdevelopment/go-dockly/v3/xredis"
+)
+
+type Server struct {
+	Name string `yaml:"name" validate:"required"`
+}
+
+type ValidateTest struct {
+	Test     string        `yaml:"test" default:"unused" validate:"required"`
+	Level    string        `yaml:"level" default:"info" validate:"oneof=debug info warn error"`
+	Endpoint string        `yaml:"endpoint" validate:"omitempty,url"`
+	Workers  int           `yaml:"workers" default:"4" validate:"min=1,max=64"`
+	Tags     []string      `yaml:"tags" default:"a,b" validate:"max=5"`
+	Interval time.Duration `yaml:"interval" default:"1m" validate:"min=1s,max=1h"`
+	Redis    xredis.Config `yaml:"redis"`
+	Servers  []Server      `yaml:"servers"`
+}
+
+func TestDefaults(t *testing.T) {
+	var cfg ValidateTest
+	if err := xconfig.SetDefaults(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != "info" || cfg.Workers != 4 || cfg.Interval != time.Minute || len(cfg.Tags) != 2 {
+		t.Error("expected the defaults to be set, got:", cfg)
+	}
+	if cfg.Redis.ConnTimeOut != 5*time.Second || cfg.Redis.PollInterval != 10*time.Second {
+		t.Error("expected the nested defaults to be set, got:", cfg.Redis)
+	}
+
+	cfg.Workers = 8
+	if err := xconfig.SetDefaults(&cfg); err != nil || cfg.Workers != 8 {
+		t.Error("expected a set field to keep its value, got:", cfg.Workers, err)
+	}
+}
+
+type DefaultsPointerTest struct {
+	Redis *xredis.Config `yaml:"redis"`
+	Cache *xredis.Config `yaml:"cache"`
+	Local xredis.Config  `yaml:"local"`
+}
+
+func TestDefaultsAllocatedPointer(t *testing.T) {
+	var cfg DefaultsPointerTest
+	if err := xconfig.LoadConfig("defaults.yaml", &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Redis == nil || cfg.Redis.Host != "localhost:6379" {
+		t.Fatal("expected the pointer to be decoded, got:", cfg.Redis)
+	}
+	if cfg.Redis.ConnTimeOut != 5*time.Second || cfg.Redis.PollInterval != 10*time.Second {
+		t.Error("expected the allocated struct to get its defaults, got:", cfg.Redis)
+	}
+	if cfg.Redis.PoolSize != 3 {
+		t.Error("expected the file to win over the defaults, got:", cfg.Redis.PoolSize)
+	}
+	if cfg.Cache != nil {
+		t.Error("expected a pointer missing from the file to stay nil, got:", cfg.Cache)
+	}
+	// an unconfigured redis is valid
+	if cfg.Local.Host != "" || cfg.Local.ConnTimeOut != 5*time.Second {
+		t.Error("expected the embedded struct to get its defaults, got:", cfg.Local)
+	}
+}
+
+func TestDefaultsFromFile(t *testing.T) {
+	var cfg xclient.Config
+	if err := xconfig.LoadConfig("cfg.yaml", &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*xclient.GetDefaultConfig(), cfg) {
+		t.Error("expected the tags to match the default client config, got:", cfg)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var cfg ValidateTest
+	err := xconfig.LoadConfig("validate.yaml", &cfg)
+
+	var verr *xconfig.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatal("expected a validation error, got:", err)
+	}
+	expected := map[string]string{
+		"Level":             "oneof",
+		"Endpoint":          "url",
+		"Redis.ConnTimeOut": "min",
+		"Servers[0].Name":   "required",
+	}
+	for _, v := range verr.Violations {
+		if rule, ok := expected[v.Field]; !ok || rule != v.Rule {
+			t.Error("unexpected violation:", v)
+		}
+		delete(expected, v.Field)
+	}
+	if len(expected) > 0 {
+		t.Error("expected all violations to be reported, missing:", expected)
+	}
+}
+
+func TestValidateBounds(t *testing.T) {
+	cfg := ValidateTest{
+		Test:     "me",
+		Level:    "debug",
+		Workers:  100,
+		Tags:     []string{"1", "2", "3", "4", "5", "6"},
+		Interval: time.Millisecond,
+		Redis:    xredis.Config{Host: "localhost", ConnTimeOut: time.Second, PollInterval: time.Second},
+	}
+	err := xconfig.Validate(&cfg)
+
+	var verr *xconfig.ValidationError
+	if !errors.As(err, &verr) || len(verr.Violations) != 3 {
+		t.Fatal("expected 3 violations, got:", err)
+	}
+
+	cfg.Workers, cfg.Tags, cfg.Interval = 64, nil, time.Hour
+	if err = xconfig.Validate(&cfg); err != nil {
+		t.Error("expected the config to be valid, got:", err)
+	}
+}
+
+func TestValidateUnknownRule(t *testing.T) {
+	cfg := struct {
+		Name string `validate:"uuid"`
+	}{}
+	if err := xconfig.Validate(&cfg); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
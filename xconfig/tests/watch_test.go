@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thisisdevelopment/go-dockly/v3/xconfig"
+)
+
+type WatchTest struct {
+	Level string `yaml:"level" validate:"oneof=debug info"`
+	Rate  int    `yaml:"rate" default:"10"`
+}
+
+// rewrite atomically replaces the file by one with the contents and modification time at
+func rewrite(t *testing.T, path, content string, at time.Time) {
+	t.Helper()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(tmp, at, at); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cfg.yaml")
+	now := time.Now()
+	rewrite(t, path, "level: info\n", now)
+
+	w, err := xconfig.Watch[WatchTest](path, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if cfg := w.Config(); cfg.Level != "info" || cfg.Rate != 10 {
+		t.Fatal("expected the initial config, got:", cfg)
+	}
+
+	notified := make(chan *WatchTest, 1)
+	w.Subscribe(func(cfg *WatchTest) {
+		notified <- cfg
+	})
+	failures := make(chan error, 1)
+	w.OnError(func(err error) {
+		select {
+		case failures <- err:
+		default:
+		}
+	})
+	changes := w.Changes()
+
+	rewrite(t, path, "level: debug\nrate: 20\n", now.Add(time.Second))
+	select {
+	case cfg := <-changes:
+		if cfg.Level != "debug" || cfg.Rate != 20 {
+			t.Error("expected the changed config, got:", cfg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a change notification")
+	}
+	// subscribers are called after the channels received the config
+	select {
+	case cfg := <-notified:
+		if cfg.Level != "debug" {
+			t.Error("expected the subscriber to get the changed config, got:", cfg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the subscriber to be called")
+	}
+
+	// an invalid config is reported and the previous one is kept
+	rewrite(t, path, "level: verbose\n", now.Add(2*time.Second))
+	select {
+	case err := <-failures:
+		if !strings.Contains(err.Error(), "Level") {
+			t.Error("expected a validation error, got:", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the invalid config to be reported")
+	}
+	if cfg := w.Config(); cfg.Level != "debug" {
+		t.Error("expected the previous config to be kept, got:", cfg)
+	}
+
+	w.Close()
+	if _, ok := <-changes; ok {
+		t.Error("expected the channel to be closed")
+	}
+	w.Close()
+}
+
+func TestWatchInitialError(t *testing.T) {
+	if _, err := xconfig.Watch[WatchTest]("missing.yaml", time.Second); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	if _, err := xconfig.Watch[WatchTest]("cfg.yaml", 0); err == nil {
+		t.Error("expected an error for an invalid interval")
+	}
+}
@@ -0,0 +1,211 @@
+package xconfig
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Violation describes a field failing one of its validation rules
+type Violation struct {
+	// Field is the path to the field, eg Redis.Hosts[1]
+	Field string
+	Rule  string
+	Msg   string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Field, v.Msg)
+}
+
+// ValidationError lists every violation found in a config
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return fmt.Sprintf("invalid config: %s", strings.Join(msgs, "; "))
+}
+
+// Validate checks cfg, which must be a pointer to a struct, against the
+// comma separated rules in the validate tags of its fields:
+//
+//	required    the field is not zero
+//	omitempty   the other rules are skipped when the field is zero
+//	min=n       numbers are at least n, strings, slices and maps have at least n elements,
+//	            durations are at least n, eg min=1s
+//	max=n       like min for an upper bound
+//	oneof=a b   the field is one of the space separated values
+//	url         the field is an absolute url
+//
+// All violations are reported at once by a *ValidationError
+func Validate(cfg any) error {
+	if !isStructPointer(cfg) {
+		return fmt.Errorf("config must be a pointer to a struct, got %T", cfg)
+	}
+	v := reflect.ValueOf(cfg)
+	var violations []Violation
+	if err := validateStruct(v.Elem(), "", &violations); err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, path string, violations *[]Violation) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		fpath := join(path, field.Name)
+
+		if tag := field.Tag.Get("validate"); tag != "" {
+			if err := validateField(fv, fpath, tag, violations); err != nil {
+				return err
+			}
+		}
+		if err := validateNested(fv, fpath, violations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNested descends into structs held by v
+func validateNested(v reflect.Value, path string, violations *[]Violation) error {
+	switch {
+	case isStruct(v.Type()):
+		return validateStruct(v, path, violations)
+	case v.Kind() == reflect.Pointer && !v.IsNil():
+		return validateNested(v.Elem(), path, violations)
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateNested(v.Index(i), fmt.Sprintf("%s[%d]", path, i), violations); err != nil {
+				return err
+			}
+		}
+	case v.Kind() == reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validateNested(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), violations); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateField(v reflect.Value, path, tag string, violations *[]Violation) error {
+	rules := strings.Split(tag, ",")
+	if v.IsZero() {
+		if slices.Contains(rules, "required") {
+			*violations = append(*violations, Violation{Field: path, Rule: "required", Msg: "is required"})
+			return nil
+		}
+		if slices.Contains(rules, "omitempty") {
+			return nil
+		}
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	for _, rule := range rules {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		var (
+			msg string
+			err error
+		)
+		switch name {
+		case "", "required", "omitempty":
+			continue
+		case "min":
+			msg, err = checkBound(v, arg, func(c int) bool { return c >= 0 }, "at least")
+		case "max":
+			msg, err = checkBound(v, arg, func(c int) bool { return c <= 0 }, "at most")
+		case "oneof":
+			options := strings.Fields(arg)
+			if s := fmt.Sprint(v.Interface()); !slices.Contains(options, s) {
+				msg = fmt.Sprintf("must be one of [%s], got %q", strings.Join(options, " "), s)
+			}
+		case "url":
+			if u, perr := url.Parse(v.String()); v.Kind() != reflect.String || perr != nil || u.Scheme == "" || u.Host == "" {
+				msg = fmt.Sprintf("must be an absolute url, got %q", v.String())
+			}
+		default:
+			err = fmt.Errorf("unknown validation rule %q", name)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid validate tag on %s: %w", path, err)
+		}
+		if msg != "" {
+			*violations = append(*violations, Violation{Field: path, Rule: name, Msg: msg})
+		}
+	}
+	return nil
+}
+
+// checkBound compares v, or its length, against arg and returns a message when ok rejects the comparison
+func checkBound(v reflect.Value, arg string, ok func(int) bool, word string) (string, error) {
+	if v.Type() == durationType {
+		bound, err := time.ParseDuration(arg)
+		if err != nil {
+			return "", err
+		}
+		if d := time.Duration(v.Int()); !ok(compare(float64(d), float64(bound))) {
+			return fmt.Sprintf("must be %s %s, got %s", word, bound, d), nil
+		}
+		return "", nil
+	}
+
+	bound, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return "", err
+	}
+	var n float64
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if l := v.Len(); !ok(compare(float64(l), bound)) {
+			return fmt.Sprintf("must have a length of %s %s, got %d", word, arg, l), nil
+		}
+		return "", nil
+	default:
+		return "", fmt.Errorf("bounds are not supported for %s", v.Type())
+	}
+	if !ok(compare(n, bound)) {
+		return fmt.Sprintf("must be %s %s, got %v", word, arg, v.Interface()), nil
+	}
+	return "", nil
+}
+
+func compare(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
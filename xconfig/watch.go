@@ -0,0 +1,167 @@
+package xconfig
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Watcher polls a config file and reloads it when it changed. Every reload
+// parses into a fresh value with the options of Watch, including defaults,
+// env overlay and validation. A file failing to load keeps the previous config
+type Watcher[T any] struct {
+	filePath string
+	opts     []Option
+	mu       sync.RWMutex
+	current  *T
+	modTime  time.Time
+	size     int64
+	subs     []func(*T)
+	chans    []chan *T
+	onError  func(error)
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// Watch loads the config file and polls it for changes every interval until
+// Close is called. It fails if the initial load fails
+func Watch[T any](filePath string, interval time.Duration, opts ...Option) (*Watcher[T], error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid poll interval %s", interval)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat file %s: %w", filePath, err)
+	}
+	cfg := new(T)
+	if err = LoadConfig(filePath, cfg, opts...); err != nil {
+		return nil, err
+	}
+
+	w := &Watcher[T]{
+		filePath: filePath,
+		opts:     opts,
+		current:  cfg,
+		modTime:  info.ModTime(),
+		size:     info.Size(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run(interval)
+
+	return w, nil
+}
+
+// Config returns the current config, which must not be modified
+func (w *Watcher[T]) Config() *T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Subscribe calls f with every new config from the polling goroutine
+func (w *Watcher[T]) Subscribe(f func(*T)) {
+	w.mu.Lock()
+	w.subs = append(w.subs, f)
+	w.mu.Unlock()
+}
+
+// Changes returns a channel receiving every new config. A slow receiver only
+// gets the latest config, the channel is closed by Close
+func (w *Watcher[T]) Changes() <-chan *T {
+	ch := make(chan *T, 1)
+	w.mu.Lock()
+	w.chans = append(w.chans, ch)
+	w.mu.Unlock()
+	return ch
+}
+
+// OnError sets an (optional) function that is called when a changed file
+// fails to load. Set to nil to disable
+func (w *Watcher[T]) OnError(f func(error)) {
+	w.mu.Lock()
+	w.onError = f
+	w.mu.Unlock()
+}
+
+// Close stops polling and waits for a running reload. It is safe to call more than once
+func (w *Watcher[T]) Close() {
+	w.once.Do(func() {
+		close(w.stop)
+		<-w.done
+
+		w.mu.Lock()
+		for _, ch := range w.chans {
+			close(ch)
+		}
+		w.chans = nil
+		w.mu.Unlock()
+	})
+}
+
+func (w *Watcher[T]) run(interval time.Duration) {
+	defer close(w.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.poll()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// poll reloads the file if its modification time or size changed
+func (w *Watcher[T]) poll() {
+	info, err := os.Stat(w.filePath)
+	if err != nil {
+		w.fail(fmt.Errorf("unable to stat file %s: %w", w.filePath, err))
+		return
+	}
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return
+	}
+	// a broken file is only retried once it changes again
+	w.modTime, w.size = info.ModTime(), info.Size()
+
+	cfg := new(T)
+	if err = LoadConfig(w.filePath, cfg, w.opts...); err != nil {
+		w.fail(err)
+		return
+	}
+
+	w.mu.Lock()
+	if reflect.DeepEqual(w.current, cfg) {
+		w.mu.Unlock()
+		return
+	}
+	w.current = cfg
+	subs := w.subs
+	for _, ch := range w.chans {
+		// replace a config the receiver did not pick up yet
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- cfg
+	}
+	w.mu.Unlock()
+
+	for _, f := range subs {
+		f(cfg)
+	}
+}
+
+func (w *Watcher[T]) fail(err error) {
+	w.mu.RLock()
+	onError := w.onError
+	w.mu.RUnlock()
+	if onError != nil {
+		onError(err)
+	}
+}
@@ -1,11 +1,268 @@
 package xcron
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
-func doEvery(d time.Duration, runFunc func()) {
-	runFunc()
+	"github.com/thisisdevelopment/go-dockly/v3/xlogger"
+)
 
-	for range time.Tick(d) {
-		runFunc()
+// Job is the unit of work run by the scheduler. The context is cancelled when
+// a shutdown stops waiting for running jobs
+type Job func(ctx context.Context) error
+
+// ErrStopped is returned when adding jobs to or starting a scheduler after its shutdown
+var ErrStopped = errors.New("xcron: scheduler is stopped")
+
+// Scheduler runs jobs on cron schedules
+type Scheduler struct {
+	log *xlogger.Logger
+	loc *time.Location
+
+	mu      sync.Mutex
+	entries map[int]*Handle
+	nextID  int
+	started bool
+	stopped bool
+
+	// stop ends the scheduling loops, ctx is handed to running jobs
+	stop   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	loops  sync.WaitGroup
+	jobs   sync.WaitGroup
+}
+
+// Option configures the scheduler
+type Option func(s *Scheduler)
+
+// WithLocation sets the time zone schedules are evaluated in, defaults to the local time zone
+func WithLocation(loc *time.Location) Option {
+	return func(s *Scheduler) {
+		s.loc = loc
+	}
+}
+
+// Handle refers to a scheduled job
+type Handle struct {
+	id       int
+	name     string
+	schedule Schedule
+	job      Job
+	removed  chan struct{}
+	once     sync.Once
+	overlap  Overlap
+	timeout  time.Duration
+	locker   Locker
+	lockTTL  time.Duration
+	jitter   time.Duration
+	store    Store
+	retries  int
+	waitMin  time.Duration
+	waitMax  time.Duration
+
+	onSuccess func(*Handle, Run)
+	onFailure func(*Handle, Run)
+
+	historySize int
+
+	mu      sync.Mutex
+	next    time.Time
+	running int
+	// activation queued by the QueueOne policy, zero if none
+	pending time.Time
+	history []Run
+}
+
+// JobOption configures a single job
+type JobOption func(h *Handle)
+
+// WithName names the job in logs, defaults to its spec
+func WithName(name string) JobOption {
+	return func(h *Handle) {
+		h.name = name
+	}
+}
+
+// New constructs a scheduler, jobs only run once it is started
+func New(log *xlogger.Logger, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		log:     log,
+		loc:     time.Local,
+		entries: make(map[int]*Handle),
+		stop:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	return s
+}
+
+// Add schedules job according to spec, see Parse for the supported syntax.
+// Jobs added to a started scheduler are scheduled right away
+func (s *Scheduler) Add(spec string, job Job, opts ...JobOption) (*Handle, error) {
+	schedule, err := Parse(spec, s.loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Schedule(schedule, job, append([]JobOption{WithName(spec)}, opts...)...)
+}
+
+// Schedule adds job with a custom schedule, see Add
+func (s *Scheduler) Schedule(schedule Schedule, job Job, opts ...JobOption) (*Handle, error) {
+	h := &Handle{
+		schedule:    schedule,
+		job:         job,
+		removed:     make(chan struct{}),
+		historySize: DefaultHistory,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return nil, ErrStopped
+	}
+	s.nextID++
+	h.id = s.nextID
+	if h.name == "" {
+		h.name = fmt.Sprintf("job-%d", h.id)
+	}
+	s.entries[h.id] = h
+	if s.started {
+		s.loops.Add(1)
+		go s.run(h)
 	}
+
+	return h, nil
+}
+
+// Remove unschedules the job, a currently running instance is not interrupted
+func (s *Scheduler) Remove(h *Handle) {
+	s.mu.Lock()
+	delete(s.entries, h.id)
+	s.mu.Unlock()
+	h.once.Do(func() {
+		close(h.removed)
+	})
+}
+
+// Handles returns all scheduled jobs
+func (s *Scheduler) Handles() []*Handle {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hs := make([]*Handle, 0, len(s.entries))
+	for _, h := range s.entries {
+		hs = append(hs, h)
+	}
+	return hs
+}
+
+// Start schedules all jobs in the background
+func (s *Scheduler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return ErrStopped
+	}
+	if s.started {
+		return nil
+	}
+	s.started = true
+	for _, h := range s.entries {
+		s.loops.Add(1)
+		go s.run(h)
+	}
+
+	return nil
+}
+
+// Shutdown stops scheduling new runs and waits for running jobs to finish.
+// When ctx is done first, the context of the running jobs is cancelled and
+// the ctx error is returned
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.stop)
+	}
+	s.mu.Unlock()
+
+	// once the loops exited no new runs are dispatched
+	s.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
+
+// run waits for each activation of the job until it is removed or the scheduler stops
+func (s *Scheduler) run(h *Handle) {
+	defer s.loops.Done()
+
+	now := time.Now()
+	if h.store != nil {
+		s.catchUp(h, now)
+	}
+	for {
+		next := h.schedule.Next(now)
+		h.setNext(next)
+		if next.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-h.removed:
+			timer.Stop()
+			return
+		case now = <-timer.C:
+			s.dispatch(h, next)
+		}
+	}
+}
+
+// ID returns the unique id of the job within its scheduler
+func (h *Handle) ID() int {
+	return h.id
+}
+
+// Name returns the name of the job
+func (h *Handle) Name() string {
+	return h.name
+}
+
+// Next returns the next time the job is scheduled to run, zero if not scheduled
+func (h *Handle) Next() time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.next
+}
+
+func (h *Handle) setNext(next time.Time) {
+	h.mu.Lock()
+	h.next = next
+	h.mu.Unlock()
 }
@@ -0,0 +1,159 @@
+package xcron
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thisisdevelopment/go-dockly/v3/xlogger"
+)
+
+func newTestScheduler(t *testing.T, opts ...Option) *Scheduler {
+	t.Helper()
+	log, err := xlogger.New(&xlogger.Config{Level: "error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(log, opts...)
+}
+
+func TestSchedulerRuns(t *testing.T) {
+	s := newTestScheduler(t)
+	var runs int32
+	h, err := s.Add("@every 10ms", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}, WithName("counter"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Name() != "counter" {
+		t.Error("unexpected job name:", h.Name())
+	}
+	if !h.Next().IsZero() {
+		t.Error("job should not be scheduled before start")
+	}
+
+	if err = s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	<-time.After(55 * time.Millisecond)
+	if err = s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	n := atomic.LoadInt32(&runs)
+	if n < 3 || n > 6 {
+		t.Error("expected about 5 runs, got:", n)
+	}
+	<-time.After(20 * time.Millisecond)
+	if atomic.LoadInt32(&runs) != n {
+		t.Error("job kept running after shutdown")
+	}
+
+	if _, err = s.Add("@every 10ms", nil); !errors.Is(err, ErrStopped) {
+		t.Error("expected ErrStopped adding to a stopped scheduler, got:", err)
+	}
+}
+
+func TestSchedulerRemove(t *testing.T) {
+	s := newTestScheduler(t)
+	s.Start()
+	defer s.Shutdown(context.Background())
+
+	var runs int32
+	h, err := s.Add("@every 10ms", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100 && atomic.LoadInt32(&runs) == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&runs) == 0 {
+		t.Fatal("job added to a started scheduler did not run")
+	}
+	s.Remove(h)
+	// removing twice is a no-op
+	s.Remove(h)
+
+	// let an already dispatched run complete
+	<-time.After(5 * time.Millisecond)
+	n := atomic.LoadInt32(&runs)
+	<-time.After(30 * time.Millisecond)
+	if atomic.LoadInt32(&runs) != n {
+		t.Error("removed job kept running")
+	}
+	if len(s.Handles()) != 0 {
+		t.Error("removed job is still listed")
+	}
+}
+
+func TestShutdownWaitsForJobs(t *testing.T) {
+	base := runtime.NumGoroutine()
+	s := newTestScheduler(t)
+
+	started := make(chan struct{})
+	var finished int32
+	s.Add("@every 5ms", func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+			return nil
+		}
+		time.Sleep(30 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+		return nil
+	})
+	s.Start()
+	<-started
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&finished) != 1 {
+		t.Error("shutdown returned before the running job finished")
+	}
+
+	for i := 0; i < 100 && runtime.NumGoroutine() > base; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > base {
+		t.Errorf("leaked goroutines: %d running, expected at most %d", n, base)
+	}
+}
+
+func TestShutdownTimeoutCancelsJobs(t *testing.T) {
+	s := newTestScheduler(t)
+
+	started := make(chan struct{}, 1)
+	cancelled := make(chan struct{})
+	s.Add("@every 5ms", func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+			return nil
+		}
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+	s.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected the shutdown to time out, got:", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("the context of the running job was not cancelled")
+	}
+}
@@ -0,0 +1,54 @@
+package xcron
+
+import "time"
+
+// DefaultHistory is the number of runs remembered per job
+const DefaultHistory = 10
+
+// Run records a single execution of a job
+type Run struct {
+	// Scheduled is the activation the run belongs to
+	Scheduled time.Time
+	// Attempt counts the retries of the activation, 0 for the first run
+	Attempt  int
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// WithHistory sets the number of runs remembered per job, 0 disables the history
+func WithHistory(n int) JobOption {
+	return func(h *Handle) {
+		h.historySize = n
+	}
+}
+
+func (h *Handle) record(run Run) {
+	if h.historySize <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.history) == h.historySize {
+		copy(h.history, h.history[1:])
+		h.history = h.history[:len(h.history)-1]
+	}
+	h.history = append(h.history, run)
+}
+
+// History returns the most recent runs of the job, oldest first
+func (h *Handle) History() []Run {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Run(nil), h.history...)
+}
+
+// LastRun returns the most recent run of the job, if any
+func (h *Handle) LastRun() (Run, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.history) == 0 {
+		return Run{}, false
+	}
+	return h.history[len(h.history)-1], true
+}
@@ -0,0 +1,163 @@
+package xcron
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	s := newTestScheduler(t)
+	var runs int32
+	h, err := s.Add("@every 5ms", func(ctx context.Context) error {
+		if atomic.AddInt32(&runs, 1)%2 == 0 {
+			return errors.New("even run")
+		}
+		return nil
+	}, WithHistory(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := h.LastRun(); ok {
+		t.Error("expected no last run before start")
+	}
+	s.Start()
+	<-time.After(40 * time.Millisecond)
+	s.Shutdown(context.Background())
+
+	history := h.History()
+	if len(history) != 3 {
+		t.Fatal("expected the history to be capped at 3, got:", len(history))
+	}
+	for i, run := range history {
+		if run.Start.IsZero() || run.Scheduled.IsZero() {
+			t.Error("expected run times to be recorded, got:", run)
+		}
+		if i > 0 && run.Start.Before(history[i-1].Start) {
+			t.Error("expected the history oldest first")
+		}
+	}
+	if (history[0].Err == nil) == (history[1].Err == nil) {
+		t.Error("expected alternating errors, got:", history[0].Err, history[1].Err)
+	}
+	last, ok := h.LastRun()
+	if !ok || last != history[2] {
+		t.Error("expected the last run to be the newest entry, got:", last)
+	}
+}
+
+func TestHistoryDisabled(t *testing.T) {
+	s := newTestScheduler(t)
+	h, _ := s.Add("@every 5ms", func(ctx context.Context) error { return nil }, WithHistory(0))
+	s.Start()
+	<-time.After(20 * time.Millisecond)
+	s.Shutdown(context.Background())
+
+	if n := len(h.History()); n != 0 {
+		t.Error("expected no history, got:", n)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	s := newTestScheduler(t)
+	h, _ := s.Add("@every 50ms", func(ctx context.Context) error { return nil }, WithJitter(5*time.Millisecond))
+	s.Start()
+	<-time.After(160 * time.Millisecond)
+	s.Shutdown(context.Background())
+
+	history := h.History()
+	if len(history) == 0 {
+		t.Fatal("expected the job to run")
+	}
+	for _, run := range history {
+		if delay := run.Start.Sub(run.Scheduled); delay > 25*time.Millisecond {
+			t.Error("expected the start to be delayed by at most the jitter, got:", delay)
+		}
+	}
+}
+
+func TestJitterStopsOnShutdown(t *testing.T) {
+	s := newTestScheduler(t)
+	var runs int32
+	s.Add("@every 1ms", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}, WithJitter(time.Hour))
+	s.Start()
+	<-time.After(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatal("expected shutdown not to wait for the jitter, got:", err)
+	}
+	if n := atomic.LoadInt32(&runs); n != 0 {
+		t.Error("expected no run within the jitter, got:", n)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "runs.json"))
+	last, err := store.LastRun("report")
+	if err != nil || !last.IsZero() {
+		t.Fatal("expected no last run on a missing file, got:", last, err)
+	}
+
+	now := time.Now().Truncate(time.Millisecond)
+	if err = store.SetLastRun("report", now); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.SetLastRun("cleanup", now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := NewFileStore(store.path)
+	last, err = reopened.LastRun("report")
+	if err != nil || !last.Equal(now) {
+		t.Error("expected the persisted last run, got:", last, err)
+	}
+}
+
+func TestCatchUp(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "runs.json"))
+	// the last run was two hours ago so the hourly activation since was missed
+	store.SetLastRun("hourly", time.Now().Add(-2*time.Hour))
+
+	s := newTestScheduler(t)
+	var runs int32
+	h, _ := s.Add("@hourly", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}, WithName("hourly"), WithCatchUp(store))
+	s.Start()
+	<-time.After(20 * time.Millisecond)
+	s.Shutdown(context.Background())
+
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Fatal("expected a single catch up run, got:", n)
+	}
+	last, _ := store.LastRun("hourly")
+	if run, _ := h.LastRun(); !last.Equal(run.Scheduled) {
+		t.Error("expected the catch up activation to be persisted, got:", last)
+	}
+}
+
+func TestCatchUpNothingMissed(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "runs.json"))
+	s := newTestScheduler(t)
+	var runs int32
+	s.Add("@hourly", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}, WithName("fresh"), WithCatchUp(store))
+	s.Start()
+	<-time.After(20 * time.Millisecond)
+	s.Shutdown(context.Background())
+
+	if n := atomic.LoadInt32(&runs); n != 0 {
+		t.Error("expected no catch up without a persisted run, got:", n)
+	}
+}
@@ -0,0 +1,169 @@
+package xcron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"time"
+
+	"github.com/logrusorgru/aurora"
+)
+
+// Overlap decides what happens when a job is due while its previous run is still going
+type Overlap int
+
+const (
+	// AllowOverlap starts the new run concurrently with the running one
+	AllowOverlap Overlap = iota
+	// SkipIfRunning drops the new run
+	SkipIfRunning
+	// QueueOne runs once more as soon as the running one finished, further
+	// activations in the meantime are dropped
+	QueueOne
+)
+
+// WithOverlap sets the overlap policy of the job, defaults to AllowOverlap
+func WithOverlap(policy Overlap) JobOption {
+	return func(h *Handle) {
+		h.overlap = policy
+	}
+}
+
+// WithTimeout cancels the context of each run after d. The job is expected to
+// return once its context is done, it is not abandoned
+func WithTimeout(d time.Duration) JobOption {
+	return func(h *Handle) {
+		h.timeout = d
+	}
+}
+
+// WithJitter delays each run by a random duration up to max, which spreads
+// the load of jobs scheduled at the same time on many instances
+func WithJitter(max time.Duration) JobOption {
+	return func(h *Handle) {
+		h.jitter = max
+	}
+}
+
+// panicError is returned for runs which panicked
+type panicError struct {
+	value any
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// dispatch starts the run of the job activated at in the background according to its overlap policy
+func (s *Scheduler) dispatch(h *Handle, at time.Time) {
+	h.mu.Lock()
+	if h.running > 0 {
+		switch h.overlap {
+		case SkipIfRunning:
+			h.mu.Unlock()
+			s.log.Debugf("skipped job %s, previous run still in progress", aurora.Yellow(h.name))
+			return
+		case QueueOne:
+			h.pending = at
+			h.mu.Unlock()
+			return
+		}
+	}
+	h.running++
+	h.mu.Unlock()
+
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		for {
+			s.claim(h, at)
+
+			h.mu.Lock()
+			if !h.pending.IsZero() && !s.stopping() {
+				at = h.pending
+				h.pending = time.Time{}
+				h.mu.Unlock()
+				continue
+			}
+			h.pending = time.Time{}
+			h.running--
+			h.mu.Unlock()
+			return
+		}
+	}()
+}
+
+// claim executes the activation at unless the job is distributed and another instance claimed it
+func (s *Scheduler) claim(h *Handle, at time.Time) {
+	if h.jitter > 0 {
+		timer := time.NewTimer(rand.N(h.jitter))
+		select {
+		case <-timer.C:
+		case <-s.stop:
+			timer.Stop()
+			return
+		}
+	}
+
+	if h.locker != nil {
+		ok, err := h.locker.TryLock(s.ctx, h.lockKey(at), h.lockTTLAt(at))
+		if err != nil {
+			s.log.Warnf("job %s failed to lock: %v", aurora.Yellow(h.name), err)
+			return
+		}
+		if !ok {
+			s.log.Debugf("job %s runs on another instance", aurora.Yellow(h.name))
+			return
+		}
+	}
+
+	s.attempt(h, at)
+
+	if h.store != nil {
+		if err := h.store.SetLastRun(h.name, at); err != nil {
+			s.log.Warnf("job %s failed to persist its last run: %v", aurora.Yellow(h.name), err)
+		}
+	}
+}
+
+// execute runs the job once, bounding it by its timeout and recovering from panics
+func (s *Scheduler) execute(h *Handle, at time.Time, attempt int) (run Run) {
+	run = Run{Scheduled: at, Attempt: attempt, Start: time.Now()}
+	ctx := s.ctx
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			run.Err = &panicError{value: r}
+		}
+		run.Duration = time.Since(run.Start)
+		h.record(run)
+
+		var perr *panicError
+		switch {
+		case errors.As(run.Err, &perr):
+			s.log.Errorf("job %s panicked: %v\n%s", aurora.Yellow(h.name), perr.value, debug.Stack())
+		case run.Err != nil:
+			s.log.Warnf("job %s failed: %v", aurora.Yellow(h.name), run.Err)
+		}
+	}()
+
+	run.Err = h.job(ctx)
+	return run
+}
+
+// stopping reports whether a shutdown is in progress
+func (s *Scheduler) stopping() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,116 @@
+package xcron
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// overlapRuns counts the runs of a job taking 25ms scheduled every 10ms for 60ms
+func overlapRuns(t *testing.T, policy Overlap) (runs, maxConcurrent int32) {
+	t.Helper()
+	s := newTestScheduler(t)
+	var running int32
+	s.Add("@every 10ms", func(ctx context.Context) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxConcurrent)
+			if n <= m || atomic.CompareAndSwapInt32(&maxConcurrent, m, n) {
+				break
+			}
+		}
+		atomic.AddInt32(&runs, 1)
+		time.Sleep(25 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	}, WithOverlap(policy))
+	s.Start()
+	<-time.After(60 * time.Millisecond)
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return atomic.LoadInt32(&runs), atomic.LoadInt32(&maxConcurrent)
+}
+
+func TestOverlapAllow(t *testing.T) {
+	_, maxConcurrent := overlapRuns(t, AllowOverlap)
+	if maxConcurrent < 2 {
+		t.Error("expected runs to overlap, got max concurrency:", maxConcurrent)
+	}
+}
+
+func TestOverlapSkip(t *testing.T) {
+	runs, maxConcurrent := overlapRuns(t, SkipIfRunning)
+	if maxConcurrent != 1 {
+		t.Error("expected runs not to overlap, got max concurrency:", maxConcurrent)
+	}
+	// activations at 10, 40 start a run, those in between are skipped
+	if runs < 1 || runs > 3 {
+		t.Error("expected overlapping activations to be skipped, got runs:", runs)
+	}
+}
+
+func TestOverlapQueueOne(t *testing.T) {
+	runs, maxConcurrent := overlapRuns(t, QueueOne)
+	if maxConcurrent != 1 {
+		t.Error("expected runs not to overlap, got max concurrency:", maxConcurrent)
+	}
+	// the run started at 10ms queues one more at 35ms, which queues another at 60ms
+	if runs < 2 {
+		t.Error("expected a queued run to follow the running one, got runs:", runs)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	s := newTestScheduler(t)
+	done := make(chan error, 1)
+	s.Add("@every 5ms", func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+		select {
+		case done <- ctx.Err():
+		default:
+		}
+		return ctx.Err()
+	}, WithTimeout(10*time.Millisecond), WithOverlap(SkipIfRunning))
+	s.Start()
+	defer s.Shutdown(context.Background())
+
+	select {
+	case err := <-done:
+		if err != context.DeadlineExceeded {
+			t.Error("expected the run to time out, got:", err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("the run was not bounded by its timeout")
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	s := newTestScheduler(t)
+	var runs int32
+	s.Add("@every 5ms", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		panic("boom")
+	})
+	s.Start()
+	<-time.After(30 * time.Millisecond)
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&runs); n < 2 {
+		t.Error("expected the job to keep running after a panic, got runs:", n)
+	}
+
+	var perr *panicError
+	run := s.execute(&Handle{name: "direct", job: func(ctx context.Context) error {
+		panic("boom")
+	}}, time.Now(), 0)
+	if err := run.Err; !errors.As(err, &perr) || perr.value != "boom" {
+		t.Error("expected the panic to be returned as error, got:", run.Err)
+	}
+}
@@ -0,0 +1,41 @@
+package xcron
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// Locker claims an activation of a job across instances, eg xredis.Redis.
+// TryLock reports whether key was acquired, the lock is left to expire after ttl
+type Locker interface {
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// WithLock runs each activation of the job only on the instance acquiring its
+// lock. The lock key is derived from the job name and activation time, so the
+// job needs the same name on every instance and a name unique among the locked
+// jobs. Activations must be aligned across instances, which holds for cron
+// expressions but not for @every which counts from each instance's start.
+// The lock is held for ttl, which should exceed the clock skew between
+// instances; it defaults to the interval to the next activation
+func WithLock(locker Locker, ttl time.Duration) JobOption {
+	return func(h *Handle) {
+		h.locker = locker
+		h.lockTTL = ttl
+	}
+}
+
+func (h *Handle) lockKey(at time.Time) string {
+	return "xcron:" + h.name + ":" + strconv.FormatInt(at.UnixMilli(), 10)
+}
+
+func (h *Handle) lockTTLAt(at time.Time) time.Duration {
+	if h.lockTTL > 0 {
+		return h.lockTTL
+	}
+	if next := h.schedule.Next(at); !next.IsZero() {
+		return next.Sub(at)
+	}
+	return time.Minute
+}
@@ -0,0 +1,99 @@
+package xcron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memLocker stands in for a redis lock shared by several instances
+type memLocker struct {
+	mu    sync.Mutex
+	held  map[string]time.Time
+	fail  bool
+	ttls  []time.Duration
+	calls int
+}
+
+func (l *memLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls++
+	if l.fail {
+		return false, errors.New("connection refused")
+	}
+	l.ttls = append(l.ttls, ttl)
+	if exp, ok := l.held[key]; ok && time.Now().Before(exp) {
+		return false, nil
+	}
+	l.held[key] = time.Now().Add(ttl)
+	return true, nil
+}
+
+// aligned activates on multiples of d like a cron expression does
+type aligned time.Duration
+
+func (a aligned) Next(t time.Time) time.Time {
+	return t.Truncate(time.Duration(a)).Add(time.Duration(a))
+}
+
+func TestLockRunsOncePerActivation(t *testing.T) {
+	locker := &memLocker{held: make(map[string]time.Time)}
+	var runs int32
+
+	var schedulers []*Scheduler
+	for i := 0; i < 3; i++ {
+		s := newTestScheduler(t)
+		_, err := s.Schedule(aligned(20*time.Millisecond), func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		}, WithName("report"), WithLock(locker, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Start()
+		schedulers = append(schedulers, s)
+	}
+
+	<-time.After(110 * time.Millisecond)
+	for _, s := range schedulers {
+		s.Shutdown(context.Background())
+	}
+
+	locker.mu.Lock()
+	defer locker.mu.Unlock()
+	if n := atomic.LoadInt32(&runs); int(n) != len(locker.held) {
+		t.Errorf("expected one run per activation, got %d runs for %d activations", n, len(locker.held))
+	}
+	if len(locker.held) < 4 {
+		t.Error("expected at least 4 activations, got:", len(locker.held))
+	}
+	if locker.calls < 2*len(locker.held) {
+		t.Error("expected every instance to try each activation, got calls:", locker.calls)
+	}
+	for _, ttl := range locker.ttls {
+		if ttl != 20*time.Millisecond {
+			t.Error("expected the lock ttl to default to the interval, got:", ttl)
+		}
+	}
+}
+
+func TestLockFailureSkipsRun(t *testing.T) {
+	locker := &memLocker{held: make(map[string]time.Time), fail: true}
+	s := newTestScheduler(t)
+	var runs int32
+	s.Add("@every 5ms", func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}, WithLock(locker, time.Second))
+	s.Start()
+	<-time.After(20 * time.Millisecond)
+	s.Shutdown(context.Background())
+
+	if n := atomic.LoadInt32(&runs); n != 0 {
+		t.Error("expected no run without the lock, got:", n)
+	}
+}
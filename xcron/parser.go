@@ -0,0 +1,320 @@
+package xcron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule describes when a job runs
+type Schedule interface {
+	// Next returns the next activation time strictly after t, or the zero time
+	// if the schedule never activates again
+	Next(t time.Time) time.Time
+}
+
+// bounds of a cron field, names are matched case insensitive
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	secondBounds = bounds{0, 59, nil}
+	minuteBounds = bounds{0, 59, nil}
+	hourBounds   = bounds{0, 23, nil}
+	domBounds    = bounds{1, 31, nil}
+	monthBounds  = bounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is accepted as sunday as well
+	dowBounds = bounds{0, 7, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// starBit marks a field given as * or ? which matters for day matching
+const starBit = 1 << 63
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// Parse a schedule spec in loc, or in the local time zone if loc is nil. Supported are
+//   - standard 5 field expressions: minute hour day-of-month month day-of-week
+//   - 6 field expressions with a leading seconds field
+//   - the descriptors @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly
+//   - @every <duration> as understood by time.ParseDuration
+//
+// Fields accept *, ?, lists (1,2), ranges (1-5), steps (*/15, 1-30/5) and month
+// and weekday names. A CRON_TZ=<zone> or TZ=<zone> prefix overrides loc
+func Parse(spec string, loc *time.Location) (Schedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		i := strings.Index(spec, " ")
+		if i == -1 {
+			return nil, fmt.Errorf("missing expression after time zone in %q", spec)
+		}
+		zone := spec[strings.Index(spec, "=")+1 : i]
+		var err error
+		if loc, err = time.LoadLocation(zone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", zone, err)
+		}
+		spec = strings.TrimSpace(spec[i:])
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration in %q: %w", spec, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("duration in %q must be positive", spec)
+		}
+		return every(d), nil
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		expr, ok := descriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("unknown descriptor %q", spec)
+		}
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("expected 5 or 6 fields in %q, got %d", spec, len(fields))
+	}
+
+	s := &specSchedule{loc: loc}
+	var err error
+	for i, f := range []struct {
+		bits *uint64
+		b    bounds
+	}{
+		{&s.second, secondBounds},
+		{&s.minute, minuteBounds},
+		{&s.hour, hourBounds},
+		{&s.dom, domBounds},
+		{&s.month, monthBounds},
+		{&s.dow, dowBounds},
+	} {
+		if *f.bits, err = parseField(fields[i], f.b); err != nil {
+			return nil, fmt.Errorf("invalid field %q in %q: %w", fields[i], spec, err)
+		}
+	}
+	// fold sunday given as 7 onto 0
+	if s.dow&(1<<7) > 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+
+	return s, nil
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		r, err := parseRange(part, b)
+		if err != nil {
+			return 0, err
+		}
+		bits |= r
+	}
+	return bits, nil
+}
+
+// parseRange parses one of *, ?, a, a-b, */n, a/n and a-b/n
+func parseRange(expr string, b bounds) (uint64, error) {
+	var (
+		start, end uint
+		step       uint = 1
+		extra      uint64
+		err        error
+	)
+
+	rangeExpr, stepExpr, hasStep := strings.Cut(expr, "/")
+	if hasStep {
+		if step, err = parseUint(stepExpr); err != nil {
+			return 0, err
+		}
+		if step == 0 {
+			return 0, fmt.Errorf("step of %q must be positive", expr)
+		}
+	}
+
+	lo, hi, isRange := strings.Cut(rangeExpr, "-")
+	switch {
+	case rangeExpr == "*" || rangeExpr == "?":
+		start, end = b.min, b.max
+		if !hasStep {
+			extra = starBit
+		}
+	case isRange:
+		if start, err = parseValue(lo, b); err != nil {
+			return 0, err
+		}
+		if end, err = parseValue(hi, b); err != nil {
+			return 0, err
+		}
+	default:
+		if start, err = parseValue(rangeExpr, b); err != nil {
+			return 0, err
+		}
+		end = start
+		// a/n means from a to the maximum
+		if hasStep {
+			end = b.max
+		}
+	}
+
+	if start < b.min || end > b.max {
+		return 0, fmt.Errorf("%q is out of range %d-%d", expr, b.min, b.max)
+	}
+	if start > end {
+		return 0, fmt.Errorf("start of %q is beyond its end", expr)
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << i
+	}
+	return bits | extra, nil
+}
+
+func parseValue(expr string, b bounds) (uint, error) {
+	if v, ok := b.names[strings.ToLower(expr)]; ok {
+		return v, nil
+	}
+	return parseUint(expr)
+}
+
+func parseUint(expr string) (uint, error) {
+	v, err := strconv.ParseUint(expr, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid number", expr)
+	}
+	return uint(v), nil
+}
+
+// every activates at a fixed interval
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// specSchedule holds one bit per allowed value of each field
+type specSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	loc                                   *time.Location
+}
+
+// Next finds the first time after t matching all fields by incrementing the
+// most significant mismatching field, resetting the lesser ones on the first increment
+func (s *specSchedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	t = t.In(s.loc)
+	// start at the next whole second
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+
+	added := false
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for 1<<uint(t.Month())&s.month == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		// midnight may not exist or be doubled on daylight saving transitions
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(time.Duration(-t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for 1<<uint(t.Hour())&s.hour == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for 1<<uint(t.Minute())&s.minute == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	for 1<<uint(t.Second())&s.second == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+
+	return t.In(origLoc)
+}
+
+// dayMatches follows cron semantics: when both day fields are restricted
+// either may match, otherwise both must
+func (s *specSchedule) dayMatches(t time.Time) bool {
+	domMatch := 1<<uint(t.Day())&s.dom > 0
+	dowMatch := 1<<uint(t.Weekday())&s.dow > 0
+	if s.dom&starBit > 0 || s.dow&starBit > 0 {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,93 @@
+package xcron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseNext(t *testing.T) {
+	ams, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Skip("time zone database unavailable:", err)
+	}
+
+	tests := []struct {
+		spec string
+		from string
+		want string
+	}{
+		{"* * * * *", "2024-01-01T10:00:30Z", "2024-01-01T10:01:00Z"},
+		{"*/15 * * * * *", "2024-01-01T10:00:01Z", "2024-01-01T10:00:15Z"},
+		{"30 9 * * *", "2024-01-01T10:00:00Z", "2024-01-02T09:30:00Z"},
+		{"0 0 1 * *", "2024-01-15T00:00:00Z", "2024-02-01T00:00:00Z"},
+		{"0 12 * * MON-FRI", "2024-01-06T00:00:00Z", "2024-01-08T12:00:00Z"},
+		{"0 0 * * 7", "2024-01-01T00:00:00Z", "2024-01-07T00:00:00Z"},
+		{"0 0 29 feb *", "2023-03-01T00:00:00Z", "2024-02-29T00:00:00Z"},
+		{"0 0 13 * 5", "2024-01-02T00:00:00Z", "2024-01-05T00:00:00Z"},
+		{"5-10/5 8 * * *", "2024-01-01T08:05:00Z", "2024-01-01T08:10:00Z"},
+		{"0 1,13 * * *", "2024-01-01T02:00:00Z", "2024-01-01T13:00:00Z"},
+		{"@daily", "2024-01-01T10:00:00Z", "2024-01-02T00:00:00Z"},
+		{"@hourly", "2024-01-01T10:20:00Z", "2024-01-01T11:00:00Z"},
+		{"@every 90s", "2024-01-01T10:00:00Z", "2024-01-01T10:01:30Z"},
+		{"CRON_TZ=Europe/Amsterdam 0 9 * * *", "2024-01-01T00:00:00Z", "2024-01-01T08:00:00Z"},
+		{"TZ=UTC 0 9 * * *", "2024-01-01T00:00:00Z", "2024-01-01T09:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.spec, time.UTC)
+		if err != nil {
+			t.Errorf("%s: %v", tt.spec, err)
+			continue
+		}
+		from, _ := time.Parse(time.RFC3339, tt.from)
+		want, _ := time.Parse(time.RFC3339, tt.want)
+		if got := s.Next(from); !got.Equal(want) {
+			t.Errorf("%s from %s: expected %s, got %s", tt.spec, tt.from, want, got)
+		}
+	}
+
+	// the scheduler location applies without a prefix
+	s, err := Parse("0 9 * * *", ams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	if got := s.Next(from); !got.Equal(time.Date(2024, 7, 1, 7, 0, 0, 0, time.UTC)) {
+		t.Error("expected 9 o'clock summer time in Amsterdam, got:", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@fortnightly",
+		"@every -1s",
+		"@every soon",
+		"CRON_TZ=Nowhere/Special * * * * *",
+		"CRON_TZ=UTC",
+	} {
+		if _, err := Parse(spec, nil); err == nil {
+			t.Errorf("expected an error parsing %q", spec)
+		}
+	}
+}
+
+func TestNeverActivates(t *testing.T) {
+	s, err := Parse("0 0 30 feb *", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := s.Next(time.Now()); !next.IsZero() {
+		t.Error("expected 30 february to never activate, got:", next)
+	}
+}
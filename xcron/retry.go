@@ -0,0 +1,81 @@
+package xcron
+
+import (
+	"time"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/thisisdevelopment/go-dockly/v3/xclient"
+)
+
+// WithRetry retries a failed run up to max times, waiting with the same
+// exponential backoff as xclient starting at waitMin and limited by waitMax.
+// Retries are abandoned when they would start after the next regular activation
+func WithRetry(max int, waitMin, waitMax time.Duration) JobOption {
+	return func(h *Handle) {
+		h.retries = max
+		h.waitMin = waitMin
+		h.waitMax = waitMax
+	}
+}
+
+// WithOnSuccess calls f after each successful run of the job
+func WithOnSuccess(f func(h *Handle, run Run)) JobOption {
+	return func(h *Handle) {
+		h.onSuccess = f
+	}
+}
+
+// WithOnFailure calls f once a run of the job failed and exhausted its
+// retries, eg to report it through an xlogger hook such as the bugsnag hook:
+//
+//	xcron.WithOnFailure(func(h *xcron.Handle, run xcron.Run) {
+//		log.WithError(run.Err).WithField("job", h.Name()).Error("cron job failed")
+//	})
+func WithOnFailure(f func(h *Handle, run Run)) JobOption {
+	return func(h *Handle) {
+		h.onFailure = f
+	}
+}
+
+// attempt executes the activation at, retrying it on failure
+func (s *Scheduler) attempt(h *Handle, at time.Time) {
+	var run Run
+	for i := 0; ; i++ {
+		run = s.execute(h, at, i)
+		if run.Err == nil || i >= h.retries || !s.backoff(h, at, i) {
+			break
+		}
+	}
+
+	switch {
+	case run.Err == nil && h.onSuccess != nil:
+		h.onSuccess(h, run)
+	case run.Err != nil && h.onFailure != nil:
+		h.onFailure(h, run)
+	}
+}
+
+// backoff waits before retry i+1 and reports whether it should go ahead
+func (s *Scheduler) backoff(h *Handle, at time.Time, i int) bool {
+	wait := xclient.Backoff(i, h.waitMin, h.waitMax)
+	now := time.Now()
+	// a caught up activation is followed by the next one from now on
+	next := h.schedule.Next(at)
+	if next.Before(now) {
+		next = h.schedule.Next(now)
+	}
+	if !next.IsZero() && now.Add(wait).After(next) {
+		s.log.Warnf("job %s gave up retrying before its next run at %s", aurora.Yellow(h.name), next)
+		return false
+	}
+	s.log.Infof("job %s retries in %s", aurora.Yellow(h.name), wait)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.stop:
+		return false
+	}
+}
@@ -0,0 +1,129 @@
+package xcron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	s := newTestScheduler(t)
+	var (
+		mu       sync.Mutex
+		attempts int
+		success  []Run
+		failure  []Run
+	)
+	h, _ := s.Add("@hourly", func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if attempts++; attempts < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	},
+		WithCatchUp(&memStore{last: time.Now().Add(-2 * time.Hour)}),
+		WithRetry(5, time.Millisecond, 4*time.Millisecond),
+		WithOnSuccess(func(h *Handle, run Run) {
+			mu.Lock()
+			success = append(success, run)
+			mu.Unlock()
+		}),
+		WithOnFailure(func(h *Handle, run Run) {
+			mu.Lock()
+			failure = append(failure, run)
+			mu.Unlock()
+		}))
+	s.Start()
+	<-time.After(30 * time.Millisecond)
+	s.Shutdown(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 {
+		t.Fatal("expected the job to succeed on its third attempt, got attempts:", attempts)
+	}
+	if len(success) != 1 || success[0].Attempt != 2 {
+		t.Error("expected a single success hook for the last attempt, got:", success)
+	}
+	if len(failure) != 0 {
+		t.Error("expected no failure hook, got:", failure)
+	}
+	history := h.History()
+	if len(history) != 3 || history[0].Err == nil || history[2].Err != nil {
+		t.Error("expected every attempt in the history, got:", history)
+	}
+	if history[1].Start.Sub(history[0].Start) < time.Millisecond {
+		t.Error("expected the retry to back off")
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	s := newTestScheduler(t)
+	var attempts, failures int32
+	s.Add("@hourly", func(ctx context.Context) error {
+		atomic.AddInt32(&attempts, 1)
+		return errors.New("down")
+	},
+		WithCatchUp(&memStore{last: time.Now().Add(-2 * time.Hour)}),
+		WithRetry(2, time.Millisecond, time.Millisecond),
+		WithOnFailure(func(h *Handle, run Run) {
+			atomic.AddInt32(&failures, 1)
+			if run.Attempt != 2 || run.Err == nil {
+				t.Error("expected the failure hook for the last attempt, got:", run)
+			}
+		}))
+	s.Start()
+	<-time.After(30 * time.Millisecond)
+	s.Shutdown(context.Background())
+
+	if n := atomic.LoadInt32(&attempts); n != 3 {
+		t.Error("expected the first run and 2 retries, got:", n)
+	}
+	if n := atomic.LoadInt32(&failures); n != 1 {
+		t.Error("expected a single failure hook, got:", n)
+	}
+}
+
+func TestRetryBeforeNextActivation(t *testing.T) {
+	s := newTestScheduler(t)
+	var attempts int32
+	h, _ := s.Schedule(aligned(20*time.Millisecond), func(ctx context.Context) error {
+		atomic.AddInt32(&attempts, 1)
+		return errors.New("down")
+	}, WithRetry(10, 50*time.Millisecond, time.Second))
+	s.Start()
+	<-time.After(70 * time.Millisecond)
+	s.Shutdown(context.Background())
+
+	for _, run := range h.History() {
+		if run.Attempt != 0 {
+			t.Error("expected no retry overlapping the next activation, got attempt:", run.Attempt)
+		}
+	}
+	if n := atomic.LoadInt32(&attempts); n < 2 {
+		t.Error("expected the regular activations to keep running, got:", n)
+	}
+}
+
+// memStore keeps the last run in memory
+type memStore struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+func (m *memStore) LastRun(name string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last, nil
+}
+
+func (m *memStore) SetLastRun(name string, t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.last = t
+	return nil
+}
@@ -0,0 +1,106 @@
+package xcron
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/logrusorgru/aurora"
+)
+
+// Store persists the last activation run per job name across restarts
+type Store interface {
+	LastRun(name string) (time.Time, error)
+	SetLastRun(name string, t time.Time) error
+}
+
+// WithCatchUp persists the last run of the job to store. When the scheduler
+// starts and an activation was missed since, eg while the process was down,
+// the job runs once right away to catch up
+func WithCatchUp(store Store) JobOption {
+	return func(h *Handle) {
+		h.store = store
+	}
+}
+
+// catchUp dispatches the first activation missed since the last persisted run
+func (s *Scheduler) catchUp(h *Handle, now time.Time) {
+	last, err := h.store.LastRun(h.name)
+	if err != nil {
+		s.log.Warnf("job %s failed to read its last run: %v", aurora.Yellow(h.name), err)
+		return
+	}
+	if last.IsZero() {
+		return
+	}
+	if missed := h.schedule.Next(last); !missed.IsZero() && missed.Before(now) {
+		s.log.Infof("job %s missed its run at %s, catching up", aurora.Yellow(h.name), missed)
+		s.dispatch(h, missed)
+	}
+}
+
+// FileStore is a Store keeping the last runs in a json file
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore returns a store persisting to path, which is created on the first write
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// LastRun returns the last run of the job name, or the zero time if it never ran
+func (f *FileStore) LastRun(name string) (time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	runs, err := f.read()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return runs[name], nil
+}
+
+// SetLastRun persists t as the last run of the job name
+func (f *FileStore) SetLastRun(name string, t time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	runs, err := f.read()
+	if err != nil {
+		return err
+	}
+	runs[name] = t
+
+	b, err := json.MarshalIndent(runs, "", " ")
+	if err != nil {
+		return fmt.Errorf("marshal last runs: %w", err)
+	}
+	// write aside and rename so a crash never leaves a truncated file
+	tmp := f.path + ".tmp"
+	if err = os.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", tmp, err)
+	}
+	if err = os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("rename %s: %w", tmp, err)
+	}
+	return nil
+}
+
+func (f *FileStore) read() (map[string]time.Time, error) {
+	runs := make(map[string]time.Time)
+	b, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return runs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", f.path, err)
+	}
+	if err = json.Unmarshal(b, &runs); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", f.path, err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,239 @@
+package xredis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/logrusorgru/aurora"
+	"github.com/pkg/errors"
+	"github.com/thisisdevelopment/go-dockly/v3/xlogger"
+)
+
+// Invalidation is the message published on the bus
+type Invalidation struct {
+	// Origin identifies the publishing bus so it can skip its own messages
+	Origin   string   `json:"origin"`
+	Keys     []string `json:"keys,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Prefixes []string `json:"prefixes,omitempty"`
+}
+
+// Invalidator is a local cache the bus applies invalidations to, eg an
+// xcache.Cache or xcache.ShardedCache keyed by string
+type Invalidator interface {
+	Delete(key string)
+	InvalidateTag(tag string) int
+	DeletePrefix(prefix string) int
+}
+
+// pubSub is the part of the redis connection the bus needs
+type pubSub interface {
+	// subscribe returns once the subscription to channel is confirmed
+	subscribe(ctx context.Context, channel string) (subscription, error)
+	publish(ctx context.Context, channel string, payload []byte) error
+	// afterReconnect registers f to be called after the connection was
+	// replaced, until remove is called
+	afterReconnect(f func()) (remove func())
+}
+
+// subscription is implemented by *redis.PubSub
+type subscription interface {
+	Channel(opts ...redis.ChannelOption) <-chan *redis.Message
+	Close() error
+}
+
+func (r *Redis) subscribe(ctx context.Context, channel string) (subscription, error) {
+	sub := r.client().Subscribe(ctx, channel)
+	// wait for the confirmation so failures surface here
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (r *Redis) publish(ctx context.Context, channel string, payload []byte) error {
+	return r.client().Publish(ctx, channel, payload).Err()
+}
+
+func (r *Redis) afterReconnect(f func()) func() {
+	// client() already returns the new connection when the hooks run
+	return r.OnReconnect(func(*redis.Client) { f() })
+}
+
+// Bus propagates invalidations of local caches across instances through redis pub/sub
+type Bus struct {
+	conn    pubSub
+	channel string
+	origin  string
+	log     *xlogger.Logger
+	// timeout bounds subscribing, which must not stall the connection check
+	timeout time.Duration
+	// unhook removes the resubscribe hook
+	unhook func()
+
+	mu     sync.Mutex
+	locals []Invalidator
+	sub    subscription
+	closed bool
+}
+
+// NewBus subscribes to channel on the connection of r, see NewRedis. The
+// subscription is renewed whenever the connection is replaced by the
+// connection check. Subscribing waits at most the configured ConnTimeOut
+func NewBus(r *Redis, channel string) (*Bus, error) {
+	return newBus(r, r.log, channel, r.config.ConnTimeOut)
+}
+
+func newBus(conn pubSub, log *xlogger.Logger, channel string, timeout time.Duration) (*Bus, error) {
+	origin := make([]byte, 8)
+	if _, err := rand.Read(origin); err != nil {
+		return nil, errors.Wrap(err, "generate bus origin")
+	}
+
+	b := &Bus{
+		conn:    conn,
+		channel: channel,
+		origin:  hex.EncodeToString(origin),
+		log:     log,
+		timeout: timeout,
+	}
+
+	if err := b.subscribe(); err != nil {
+		return nil, err
+	}
+
+	unhook := conn.afterReconnect(func() {
+		if err := b.subscribe(); err != nil {
+			b.log.Warnf("resubscribe to %s failed: %v", aurora.Yellow(channel), err)
+		}
+	})
+
+	b.mu.Lock()
+	b.unhook = unhook
+	b.mu.Unlock()
+
+	return b, nil
+}
+
+// subscribe listens on the channel, replacing any previous subscription
+func (b *Bus) subscribe() error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+
+	sub, err := b.conn.subscribe(ctx, b.channel)
+	if err != nil {
+		return errors.Wrapf(err, "subscribe %s", aurora.Yellow(b.channel))
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return sub.Close()
+	}
+	old := b.sub
+	b.sub = sub
+	b.mu.Unlock()
+
+	if old != nil {
+		// ends the listen loop of the previous subscription
+		old.Close()
+	}
+
+	go b.listen(sub)
+
+	return nil
+}
+
+func (b *Bus) listen(sub subscription) {
+	for msg := range sub.Channel() {
+		b.handle(msg.Payload)
+	}
+}
+
+func (b *Bus) handle(payload string) {
+	var inv Invalidation
+	if err := json.Unmarshal([]byte(payload), &inv); err != nil {
+		b.log.Warnf("invalid message on %s: %v", aurora.Yellow(b.channel), err)
+		return
+	}
+
+	// our own invalidations were applied when published
+	if inv.Origin == b.origin {
+		return
+	}
+
+	b.apply(inv)
+}
+
+func (b *Bus) apply(inv Invalidation) {
+	b.mu.Lock()
+	locals := b.locals
+	b.mu.Unlock()
+
+	for _, local := range locals {
+		for _, key := range inv.Keys {
+			local.Delete(key)
+		}
+		for _, tag := range inv.Tags {
+			local.InvalidateTag(tag)
+		}
+		for _, prefix := range inv.Prefixes {
+			local.DeletePrefix(prefix)
+		}
+	}
+}
+
+// Subscribe registers a local cache to apply the invalidations received on the bus to
+func (b *Bus) Subscribe(local Invalidator) {
+	b.mu.Lock()
+	b.locals = append(b.locals, local)
+	b.mu.Unlock()
+}
+
+// Publish applies inv to the local caches of this instance and sends it to all others
+func (b *Bus) Publish(ctx context.Context, inv Invalidation) error {
+	inv.Origin = b.origin
+	b.apply(inv)
+
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		return errors.Wrap(err, "marshal invalidation")
+	}
+
+	if err = b.conn.publish(ctx, b.channel, payload); err != nil {
+		return errors.Wrapf(err, "publish %s", aurora.Yellow(b.channel))
+	}
+
+	return nil
+}
+
+// InvalidateKeys deletes keys from the local caches of all instances
+func (b *Bus) InvalidateKeys(ctx context.Context, keys ...string) error {
+	return b.Publish(ctx, Invalidation{Keys: keys})
+}
+
+// InvalidateTags removes the items tagged with any of tags from the local caches of all instances
+func (b *Bus) InvalidateTags(ctx context.Context, tags ...string) error {
+	return b.Publish(ctx, Invalidation{Tags: tags})
+}
+
+// Close ends the subscription, local caches are no longer invalidated
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	if b.unhook != nil {
+		b.unhook()
+		b.unhook = nil
+	}
+	if b.sub == nil {
+		return nil
+	}
+	return b.sub.Close()
+}
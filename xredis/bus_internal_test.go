@@ -0,0 +1,270 @@
+package xredis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+	"github.com/thisisdevelopment/go-dockly/v3/xcache"
+	"github.com/thisisdevelopment/go-dockly/v3/xlogger"
+)
+
+func TestBusHandle(t *testing.T) {
+	log, err := xlogger.New(new(xlogger.Config))
+	require.NoError(t, err)
+
+	local := xcache.NewCache[string, int](time.Minute, 0)
+	b := &Bus{channel: "invalidate", origin: "self", log: log}
+	b.Subscribe(local)
+
+	local.Set("a", 1, xcache.DefaultExpiration)
+	local.SetWithTags("b", 2, xcache.DefaultExpiration, "tag")
+	local.Set("user:1", 3, xcache.DefaultExpiration)
+	local.Set("c", 4, xcache.DefaultExpiration)
+
+	payload, err := json.Marshal(Invalidation{Origin: "self", Keys: []string{"c"}})
+	require.NoError(t, err)
+	b.handle(string(payload))
+	_, found := local.Get("c")
+	require.True(t, found, "own invalidations must be skipped when received")
+
+	payload, err = json.Marshal(Invalidation{
+		Origin:   "other",
+		Keys:     []string{"a"},
+		Tags:     []string{"tag"},
+		Prefixes: []string{"user:"},
+	})
+	require.NoError(t, err)
+	b.handle(string(payload))
+
+	require.Equal(t, []string{"c"}, keys(local.Items()))
+
+	// garbage is logged and ignored
+	b.handle("{")
+	require.Equal(t, 1, local.ItemCount())
+}
+
+func keys[V any](items map[string]xcache.TypedItem[V]) []string {
+	var ks []string
+	for k := range items {
+		ks = append(ks, k)
+	}
+	return ks
+}
+
+// fakePubSub delivers published messages to the open subscriptions in memory
+type fakePubSub struct {
+	mu        sync.Mutex
+	subs      []*fakeSub
+	published [][]byte
+	failSub   error
+	failPub   error
+	// hang makes subscribe wait for its context like a stalled connection
+	hang       bool
+	reconnects map[int]func()
+	nextHook   int
+}
+
+type fakeSub struct {
+	ch     chan *redis.Message
+	closed bool
+	close  func(*fakeSub)
+}
+
+func (s *fakeSub) Channel(opts ...redis.ChannelOption) <-chan *redis.Message {
+	return s.ch
+}
+
+func (s *fakeSub) Close() error {
+	s.close(s)
+	return nil
+}
+
+func (f *fakePubSub) subscribe(ctx context.Context, channel string) (subscription, error) {
+	f.mu.Lock()
+	if f.hang {
+		f.mu.Unlock()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	defer f.mu.Unlock()
+	if f.failSub != nil {
+		return nil, f.failSub
+	}
+	sub := &fakeSub{ch: make(chan *redis.Message, 16)}
+	sub.close = f.closeSub
+	f.subs = append(f.subs, sub)
+	return sub, nil
+}
+
+func (f *fakePubSub) publish(ctx context.Context, channel string, payload []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failPub != nil {
+		return f.failPub
+	}
+	f.published = append(f.published, payload)
+	for _, sub := range f.subs {
+		if !sub.closed {
+			sub.ch <- &redis.Message{Channel: channel, Payload: string(payload)}
+		}
+	}
+	return nil
+}
+
+func (f *fakePubSub) afterReconnect(fn func()) func() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.reconnects == nil {
+		f.reconnects = make(map[int]func())
+	}
+	id := f.nextHook
+	f.nextHook++
+	f.reconnects[id] = fn
+	return func() {
+		f.mu.Lock()
+		delete(f.reconnects, id)
+		f.mu.Unlock()
+	}
+}
+
+// reconnect runs the hooks like the connection check after replacing the client
+func (f *fakePubSub) reconnect() {
+	f.mu.Lock()
+	var hooks []func()
+	for _, fn := range f.reconnects {
+		hooks = append(hooks, fn)
+	}
+	f.mu.Unlock()
+	for _, fn := range hooks {
+		fn()
+	}
+}
+
+// open returns the number of subscriptions not closed yet
+func (f *fakePubSub) open() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n int
+	for _, sub := range f.subs {
+		if !sub.closed {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakePubSub) closeSub(sub *fakeSub) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !sub.closed {
+		sub.closed = true
+		close(sub.ch)
+	}
+}
+
+func newTestBus(t *testing.T, conn pubSub) (*Bus, *xcache.TypedCache[string, int]) {
+	t.Helper()
+	log, err := xlogger.New(new(xlogger.Config))
+	require.NoError(t, err)
+
+	b, err := newBus(conn, log, "invalidate", 50*time.Millisecond)
+	require.NoError(t, err)
+	t.Cleanup(func() { b.Close() })
+
+	local := xcache.NewCache[string, int](time.Minute, 0)
+	local.Set("a", 1, xcache.DefaultExpiration)
+	local.Set("b", 2, xcache.DefaultExpiration)
+	b.Subscribe(local)
+
+	return b, local
+}
+
+func TestBusPublish(t *testing.T) {
+	conn := new(fakePubSub)
+	self, own := newTestBus(t, conn)
+	_, other := newTestBus(t, conn)
+
+	require.NoError(t, self.InvalidateKeys(context.Background(), "a"))
+
+	// applied locally before publishing
+	_, found := own.Get("a")
+	require.False(t, found)
+	require.Eventually(t, func() bool {
+		_, found := other.Get("a")
+		return !found
+	}, time.Second, time.Millisecond)
+	require.Equal(t, 1, other.ItemCount())
+
+	var inv Invalidation
+	require.Len(t, conn.published, 1)
+	require.NoError(t, json.Unmarshal(conn.published[0], &inv))
+	require.Equal(t, Invalidation{Origin: self.origin, Keys: []string{"a"}}, inv)
+
+	conn.mu.Lock()
+	conn.failPub = errors.New("connection refused")
+	conn.mu.Unlock()
+	err := self.InvalidateKeys(context.Background(), "b")
+	require.ErrorContains(t, err, "connection refused")
+	_, found = own.Get("b")
+	require.False(t, found, "local caches are invalidated even if publishing fails")
+}
+
+func TestBusSubscribeFails(t *testing.T) {
+	log, err := xlogger.New(new(xlogger.Config))
+	require.NoError(t, err)
+
+	_, err = newBus(&fakePubSub{failSub: errors.New("connection refused")}, log, "invalidate", time.Second)
+	require.ErrorContains(t, err, "connection refused")
+}
+
+func TestBusResubscribe(t *testing.T) {
+	conn := new(fakePubSub)
+	self, _ := newTestBus(t, conn)
+	_, other := newTestBus(t, conn)
+	require.Equal(t, 2, conn.open())
+
+	// the previous subscription is replaced on reconnect
+	conn.reconnect()
+	require.Equal(t, 2, conn.open())
+	require.Len(t, conn.subs, 4)
+
+	require.NoError(t, self.InvalidateKeys(context.Background(), "a"))
+	require.Eventually(t, func() bool {
+		_, found := other.Get("a")
+		return !found
+	}, time.Second, time.Millisecond)
+
+	// a failed resubscribe is logged and keeps the current subscription
+	conn.mu.Lock()
+	conn.failSub = errors.New("connection refused")
+	conn.mu.Unlock()
+	conn.reconnect()
+	require.Equal(t, 2, conn.open())
+
+	// a stalled resubscribe gives up after the timeout instead of blocking
+	// the connection check
+	conn.mu.Lock()
+	conn.failSub = nil
+	conn.hang = true
+	conn.mu.Unlock()
+	start := time.Now()
+	conn.reconnect()
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, 2, conn.open())
+
+	// Close removes the hook, no subscription survives it, not even one
+	// made by a resubscribe already running
+	conn.mu.Lock()
+	conn.hang = false
+	conn.mu.Unlock()
+	require.NoError(t, self.Close())
+	require.Len(t, conn.reconnects, 1)
+	require.NoError(t, self.subscribe())
+	require.Equal(t, 1, conn.open())
+}
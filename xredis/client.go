@@ -3,6 +3,7 @@ package xredis
 import (
 	"context"
 	"crypto/tls"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -24,25 +25,38 @@ type ICache interface {
 
 // Redis implements the ICache interface based on redis
 type Redis struct {
-	redis  *redis.Client
-	config *Config
-	log    *xlogger.Logger
+	mu          sync.RWMutex
+	redis       *redis.Client
+	config      *Config
+	log         *xlogger.Logger
+	onReconnect map[int]func(*redis.Client)
+	nextHook    int
 }
 
 type Config struct {
 	Host         string
-	Pass         string
-	DB           int
-	Expiration   int
-	PoolSize     int           `yaml:"pool_size"`
-	MaxRetries   int           `yaml:"max_retries"`
-	ConnTimeOut  time.Duration `yaml:"conn_timeout"`
-	PollInterval time.Duration `yaml:"poll_interval"`
+	Pass         string        `secret:"true"`
+	DB           int           `validate:"min=0"`
+	Expiration   int           `validate:"min=0"`
+	PoolSize     int           `yaml:"pool_size" validate:"min=0"`
+	MaxRetries   int           `yaml:"max_retries" validate:"min=0"`
+	ConnTimeOut  time.Duration `yaml:"conn_timeout" default:"5s" validate:"min=1ms"`
+	PollInterval time.Duration `yaml:"poll_interval" default:"10s" validate:"min=1ms"`
 	TLS          bool
 }
 
 // New constructs a cache class
 func New(config *Config, log *xlogger.Logger) (ICache, error) {
+	r, err := NewRedis(config, log)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewRedis constructs a cache class like New, returning the concrete type
+// needed for the locks and the invalidation bus
+func NewRedis(config *Config, log *xlogger.Logger) (*Redis, error) {
 
 	var opts = defaultOpts(config)
 	var client = redis.NewClient(opts)
@@ -84,25 +98,71 @@ func defaultOpts(config *Config) *redis.Options {
 	return opts
 }
 
+// client returns the current connection which checkConnection may replace at any time
+func (r *Redis) client() *redis.Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.redis
+}
+
+// OnReconnect registers f to be called with the new client whenever
+// checkConnection had to replace a broken connection, eg to re-subscribe.
+// The returned function unregisters f
+func (r *Redis) OnReconnect(f func(*redis.Client)) (remove func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.onReconnect == nil {
+		r.onReconnect = make(map[int]func(*redis.Client))
+	}
+	id := r.nextHook
+	r.nextHook++
+	r.onReconnect[id] = f
+
+	return func() {
+		r.mu.Lock()
+		delete(r.onReconnect, id)
+		r.mu.Unlock()
+	}
+}
+
 // pings the connection at tick interval and tries to continously reconnect on error
 func (r *Redis) checkConnection() {
+	// tracks whether the connection was replaced without notifying OnReconnect yet
+	var replaced bool
 
 	for range time.Tick(r.config.PollInterval) {
 		ctx, cancel := context.WithTimeout(context.Background(), r.config.PollInterval)
 
-		err := r.redis.Ping(ctx).Err()
+		client := r.client()
+		err := client.Ping(ctx).Err()
 		if err != nil {
 			// redis disconnected
 			opts := defaultOpts(r.config)
-			client := redis.NewClient(opts)
+			client = redis.NewClient(opts)
+			r.mu.Lock()
 			r.redis = client
+			r.mu.Unlock()
+			replaced = true
 
-			if err := r.redis.Ping(ctx).Err(); err != nil {
+			if err := client.Ping(ctx).Err(); err != nil {
 				r.log.Warningln("redis connnection failed, failed to set new one")
 				cancel()
 				continue
 			}
 		}
 		cancel()
+
+		if replaced {
+			replaced = false
+			r.mu.RLock()
+			hooks := make([]func(*redis.Client), 0, len(r.onReconnect))
+			for _, f := range r.onReconnect {
+				hooks = append(hooks, f)
+			}
+			r.mu.RUnlock()
+			for _, f := range hooks {
+				f(client)
+			}
+		}
 	}
 }
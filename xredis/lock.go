@@ -0,0 +1,168 @@
+package xredis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/logrusorgru/aurora"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNotObtained is returned when the lock is held by someone else
+	ErrNotObtained = errors.New("lock not obtained")
+	// ErrNotHeld is returned when releasing or refreshing a lock which expired or was taken over
+	ErrNotHeld = errors.New("lock not held")
+)
+
+// obtains the lock and issues the next fencing token atomically. The fence
+// counter outlives the lock so tokens keep increasing, which makes Obtain
+// meant for a stable set of keys
+const obtainSource = `
+if redis.call("set", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("incr", KEYS[2])
+end
+return false
+`
+
+// only the owner may delete the lock
+const releaseSource = `
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`
+
+// only the owner may extend the lock
+const refreshSource = `
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0
+`
+
+var (
+	obtainScript  = redis.NewScript(obtainSource)
+	releaseScript = redis.NewScript(releaseSource)
+	refreshScript = redis.NewScript(refreshSource)
+)
+
+// lockClient is the part of the redis client locks run on
+type lockClient interface {
+	redis.Scripter
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+}
+
+// Lock is a distributed lock held on a redis key
+type Lock struct {
+	client func() lockClient
+	key    string
+	owner  string
+	token  int64
+}
+
+// lockClient returns the current connection for locks
+func (r *Redis) lockClient() lockClient {
+	return r.client()
+}
+
+// Obtain acquires the lock on key for ttl or returns ErrNotObtained when it is
+// held elsewhere. Every obtained lock on a key carries a strictly increasing
+// fencing token, which protected resources can use to reject stale holders.
+// The token counter is kept in key:fence without expiry, so key should not be
+// derived from ever changing values such as timestamps, use TryLock for those
+func (r *Redis) Obtain(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	return obtain(ctx, r.lockClient, key, ttl)
+}
+
+func obtain(ctx context.Context, client func() lockClient, key string, ttl time.Duration) (*Lock, error) {
+	owner, err := newOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Lock{
+		client: client,
+		key:    key,
+		owner:  owner,
+	}
+
+	token, err := obtainScript.Run(ctx, client(), []string{key, key + ":fence"}, l.owner, ttl.Milliseconds()).Int64()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotObtained
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "obtain lock %s", aurora.Yellow(key))
+	}
+	l.token = token
+
+	return l, nil
+}
+
+// TryLock acquires the lock on key for ttl and reports whether it succeeded.
+// The lock is left to expire and carries no fencing token, which makes it
+// suitable to claim one-off work such as a single cron activation across instances
+func (r *Redis) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return tryLock(ctx, r.lockClient(), key, ttl)
+}
+
+func tryLock(ctx context.Context, client lockClient, key string, ttl time.Duration) (bool, error) {
+	owner, err := newOwner()
+	if err != nil {
+		return false, err
+	}
+
+	ok, err := client.SetNX(ctx, key, owner, ttl).Result()
+	if err != nil {
+		return false, errors.Wrapf(err, "try lock %s", aurora.Yellow(key))
+	}
+
+	return ok, nil
+}
+
+func newOwner() (string, error) {
+	owner := make([]byte, 16)
+	if _, err := rand.Read(owner); err != nil {
+		return "", errors.Wrap(err, "generate lock owner")
+	}
+	return hex.EncodeToString(owner), nil
+}
+
+// Key returns the locked redis key
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Token returns the fencing token issued when the lock was obtained
+func (l *Lock) Token() int64 {
+	return l.token
+}
+
+// Refresh extends the lock to ttl from now, or returns ErrNotHeld when it was lost
+func (l *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	n, err := refreshScript.Run(ctx, l.client(), []string{l.key}, l.owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return errors.Wrapf(err, "refresh lock %s", aurora.Yellow(l.key))
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+
+	return nil
+}
+
+// Release frees the lock if it is still held, or returns ErrNotHeld when it was lost
+func (l *Lock) Release(ctx context.Context) error {
+	n, err := releaseScript.Run(ctx, l.client(), []string{l.key}, l.owner).Int64()
+	if err != nil {
+		return errors.Wrapf(err, "release lock %s", aurora.Yellow(l.key))
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+
+	return nil
+}
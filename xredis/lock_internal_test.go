@@ -0,0 +1,181 @@
+package xredis
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptRedis emulates the commands and lock scripts on an in-memory keyspace
+type scriptRedis struct {
+	mu    sync.Mutex
+	items map[string]scriptItem
+}
+
+type scriptItem struct {
+	val string
+	exp time.Time
+}
+
+func newScriptRedis() *scriptRedis {
+	return &scriptRedis{items: make(map[string]scriptItem)}
+}
+
+func (m *scriptRedis) get(key string) (scriptItem, bool) {
+	item, ok := m.items[key]
+	if ok && !item.exp.IsZero() && time.Now().After(item.exp) {
+		delete(m.items, key)
+		return scriptItem{}, false
+	}
+	return item, ok
+}
+
+func (m *scriptRedis) setNX(key, val string, ttl time.Duration) bool {
+	if _, ok := m.get(key); ok {
+		return false
+	}
+	item := scriptItem{val: val}
+	if ttl > 0 {
+		item.exp = time.Now().Add(ttl)
+	}
+	m.items[key] = item
+	return true
+}
+
+func (m *scriptRedis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return redis.NewBoolResult(m.setNX(key, value.(string), expiration), nil)
+}
+
+func (m *scriptRedis) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	// makes Script.Run fall back to Eval with the source
+	return redis.NewCmdResult(nil, errors.New("NOSCRIPT No matching script"))
+}
+
+func (m *scriptRedis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	owner := args[0].(string)
+	item, held := m.get(keys[0])
+	switch script {
+	case obtainSource:
+		if !m.setNX(keys[0], owner, time.Duration(args[1].(int64))*time.Millisecond) {
+			return redis.NewCmdResult(nil, redis.Nil)
+		}
+		n, _ := strconv.ParseInt(m.items[keys[1]].val, 10, 64)
+		m.items[keys[1]] = scriptItem{val: strconv.FormatInt(n+1, 10)}
+		return redis.NewCmdResult(n+1, nil)
+	case releaseSource:
+		if !held || item.val != owner {
+			return redis.NewCmdResult(int64(0), nil)
+		}
+		delete(m.items, keys[0])
+		return redis.NewCmdResult(int64(1), nil)
+	case refreshSource:
+		if !held || item.val != owner {
+			return redis.NewCmdResult(int64(0), nil)
+		}
+		item.exp = time.Now().Add(time.Duration(args[1].(int64)) * time.Millisecond)
+		m.items[keys[0]] = item
+		return redis.NewCmdResult(int64(1), nil)
+	}
+	return redis.NewCmdResult(nil, errors.New("unknown script"))
+}
+
+func (m *scriptRedis) ScriptExists(ctx context.Context, hashes ...string) *redis.BoolSliceCmd {
+	return redis.NewBoolSliceResult(make([]bool, len(hashes)), nil)
+}
+
+func (m *scriptRedis) ScriptLoad(ctx context.Context, script string) *redis.StringCmd {
+	return redis.NewStringResult("", nil)
+}
+
+func (m *scriptRedis) ttl(key string) (time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.get(key)
+	if !ok || item.exp.IsZero() {
+		return -1, ok
+	}
+	return time.Until(item.exp), true
+}
+
+func TestLock(t *testing.T) {
+	m := newScriptRedis()
+	testLock(t, func() lockClient { return m }, "lock")
+
+	// a claimed activation leaves nothing but its own expiring key
+	m.items = make(map[string]scriptItem)
+	ok, err := tryLock(context.Background(), m, "xcron:job:1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, m.items, 1)
+	ttl, found := m.ttl("xcron:job:1")
+	require.True(t, found)
+	require.Greater(t, ttl, time.Duration(0))
+}
+
+// TestLockRedis runs the lock scripts on a real redis given by XREDIS_TEST_HOST
+func TestLockRedis(t *testing.T) {
+	host := os.Getenv("XREDIS_TEST_HOST")
+	if host == "" {
+		t.Skip("XREDIS_TEST_HOST not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: host})
+	defer client.Close()
+
+	key := "xredis:test:lock:" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	defer client.Del(context.Background(), key, key+":fence", key+":try")
+	testLock(t, func() lockClient { return client }, key)
+
+	ok, err := tryLock(context.Background(), client, key+":try", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Greater(t, client.PTTL(context.Background(), key+":try").Val(), time.Duration(0))
+}
+
+func testLock(t *testing.T, client func() lockClient, key string) {
+	ctx := context.Background()
+
+	l, err := obtain(ctx, client, key, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, key, l.Key())
+	first := l.Token()
+
+	_, err = obtain(ctx, client, key, time.Minute)
+	require.ErrorIs(t, err, ErrNotObtained)
+
+	ok, err := tryLock(ctx, client(), key, time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok, "a held lock must not be claimed")
+
+	require.NoError(t, l.Refresh(ctx, time.Minute))
+
+	// another owner can neither refresh nor release the lock
+	stranger := &Lock{client: client, key: key, owner: "stranger"}
+	require.ErrorIs(t, stranger.Refresh(ctx, time.Minute), ErrNotHeld)
+	require.ErrorIs(t, stranger.Release(ctx), ErrNotHeld)
+
+	require.NoError(t, l.Release(ctx))
+	require.ErrorIs(t, l.Release(ctx), ErrNotHeld)
+
+	l, err = obtain(ctx, client, key, 50*time.Millisecond)
+	require.NoError(t, err)
+	require.Greater(t, l.Token(), first, "fencing tokens must increase")
+
+	// an expired lock is lost and can be obtained again
+	<-time.After(100 * time.Millisecond)
+	require.ErrorIs(t, l.Refresh(ctx, time.Minute), ErrNotHeld)
+	l, err = obtain(ctx, client, key, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, l.Release(ctx))
+}
@@ -42,13 +42,13 @@ func (c *Redis) Set(ctx context.Context, key string, value interface{}, t time.D
 		}
 	}
 
-	return c.redis.Set(ctx, key, g, t).Err()
+	return c.client().Set(ctx, key, g, t).Err()
 }
 
 // Get will return value of key under cancellable context and try unmarshal the result into expected
 func (c *Redis) Get(ctx context.Context, key string, expected interface{}) error {
 
-	val, err := c.redis.Get(ctx, key).Bytes()
+	val, err := c.client().Get(ctx, key).Bytes()
 	if err != nil {
 		return errors.Wrapf(err, "get %s", aurora.Yellow(key))
 	}
@@ -60,6 +60,24 @@ func (c *Redis) Get(ctx context.Context, key string, expected interface{}) error
 		return errors.Wrapf(err, "gunzip %s", aurora.Yellow(key))
 	}
 
+	return unmarshal(key, b, expected)
+}
+
+// marshal returns byte slices as is assuming they are already marshalled, anything else is json marshalled
+func marshal(key string, value interface{}) ([]byte, error) {
+	if v, ok := value.([]byte); ok {
+		return v, nil
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal %s", aurora.Yellow(key))
+	}
+
+	return b, nil
+}
+
+func unmarshal(key string, b []byte, expected interface{}) error {
 	switch expected.(type) {
 	// the special case bypassing an unmarshal indicating a different format otherwise json is assumed
 	case *[]byte:
@@ -67,7 +85,7 @@ func (c *Redis) Get(ctx context.Context, key string, expected interface{}) error
 		reflect.ValueOf(expected).Elem().Set(reflect.ValueOf(b))
 	default:
 		// we handle the payload and unmarshal into the expected interface directly
-		if err = json.Unmarshal(b, expected); err != nil {
+		if err := json.Unmarshal(b, expected); err != nil {
 			return errors.Wrapf(err, "unmarshal %s ", aurora.Yellow(key))
 		}
 	}
@@ -84,7 +102,7 @@ func (c *Redis) GetCacheKeys(ctx context.Context, keyPattern string) (ks []strin
 		var keys []string
 		var err error
 		// only string keys are returned no payloads
-		keys, cursor, err = c.redis.Scan(ctx, cursor, keyPattern, 512).Result()
+		keys, cursor, err = c.client().Scan(ctx, cursor, keyPattern, 512).Result()
 		if err != nil {
 			return nil, err
 		}
@@ -104,9 +122,10 @@ func (c *Redis) GetCacheKeys(ctx context.Context, keyPattern string) (ks []strin
 }
 
 // GetTTL returns the remaining time to live duration for key or 0 if expired
+// or without expiry, see GetPTTL to tell these apart
 func (c *Redis) GetTTL(ctx context.Context, key string) (time.Duration, error) {
 
-	ttl, err := c.redis.TTL(ctx, key).Result()
+	ttl, err := c.client().TTL(ctx, key).Result()
 	if err != nil {
 		return 0, err
 	}
@@ -117,3 +136,28 @@ func (c *Redis) GetTTL(ctx context.Context, key string) (time.Duration, error) {
 
 	return ttl, err
 }
+
+// NoExpiration is the ttl GetPTTL returns for keys which never expire, it
+// matches xcache.NoExpiration
+const NoExpiration time.Duration = -1
+
+// GetPTTL returns the remaining time to live of key in milliseconds precision
+// and whether the key exists. A key without expiry returns NoExpiration
+func (c *Redis) GetPTTL(ctx context.Context, key string) (time.Duration, bool, error) {
+
+	ttl, err := c.client().PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch ttl {
+	// -2 if the key does not exist
+	case -2:
+		return 0, false, nil
+	// -1 if the key exists but has no associated expire
+	case -1:
+		return NoExpiration, true, nil
+	}
+
+	return ttl, true, nil
+}
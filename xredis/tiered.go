@@ -0,0 +1,118 @@
+package xredis
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/pkg/errors"
+	"github.com/thisisdevelopment/go-dockly/v3/xcache"
+)
+
+// Tiered implements the ICache interface with a local in-process cache (L1)
+// in front of a remote cache such as Redis (L2). Values are kept marshalled in
+// L1 so every Get hands out a fresh copy, just like a Redis roundtrip would
+type Tiered struct {
+	local  *xcache.TypedCache[string, []byte]
+	remote ICache
+}
+
+var _ ICache = (*Tiered)(nil)
+
+// preciseTTL is implemented by remotes like Redis which tell keys without
+// expiry apart from missing ones, see Redis.GetPTTL
+type preciseTTL interface {
+	GetPTTL(ctx context.Context, key string) (time.Duration, bool, error)
+}
+
+// NewTiered constructs a two tier cache. Writes go through to both tiers, reads
+// fall back to remote and back-fill local with the remaining remote TTL. Keys
+// without expiry are only back-filled from remotes implementing GetPTTL like Redis
+func NewTiered(local *xcache.TypedCache[string, []byte], remote ICache) *Tiered {
+	return &Tiered{
+		local:  local,
+		remote: remote,
+	}
+}
+
+// Set the value to key in both tiers, a TTL of 0 uses the local default
+// expiration and the remote config expiration respectively
+func (t *Tiered) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	b, err := marshal(key, value)
+	if err != nil {
+		return err
+	}
+
+	// remote stores the byte slice as is, so both tiers hold the same payload
+	if err = t.remote.Set(ctx, key, b, ttl); err != nil {
+		return errors.Wrapf(err, "set remote %s", aurora.Yellow(key))
+	}
+
+	if _, ok := value.([]byte); ok {
+		// the caller may reuse its slice
+		b = bytes.Clone(b)
+	}
+	t.local.Set(key, b, ttl)
+
+	return nil
+}
+
+// Get will return value of key from local or else remote and try unmarshal the result into expected
+func (t *Tiered) Get(ctx context.Context, key string, expected interface{}) error {
+	if b, found := t.local.Get(key); found {
+		return unmarshalCopy(key, b, expected)
+	}
+
+	var b []byte
+	if err := t.remote.Get(ctx, key, &b); err != nil {
+		return err
+	}
+
+	ttl, found, err := t.remoteTTL(ctx, key)
+	if err != nil {
+		return errors.Wrapf(err, "get remote ttl %s", aurora.Yellow(key))
+	}
+
+	// a key which expired meanwhile or is about to must not live on locally
+	if found && ttl != 0 {
+		t.local.Set(key, b, ttl)
+	}
+
+	return unmarshalCopy(key, b, expected)
+}
+
+// remoteTTL returns the remaining remote time to live of key and whether
+// it can be back-filled, which is NoExpiration for keys without expiry
+func (t *Tiered) remoteTTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	if r, ok := t.remote.(preciseTTL); ok {
+		return r.GetPTTL(ctx, key)
+	}
+	// 0 means missing as well as without expiry, neither is back-filled
+	ttl, err := t.remote.GetTTL(ctx, key)
+	return ttl, ttl > 0, err
+}
+
+// unmarshalCopy is unmarshal for payloads held by the local tier, raw byte
+// slices are copied so callers cannot modify the stored value
+func unmarshalCopy(key string, b []byte, expected interface{}) error {
+	if _, ok := expected.(*[]byte); ok {
+		b = bytes.Clone(b)
+	}
+	return unmarshal(key, b, expected)
+}
+
+// GetCacheKeys returns all the keys in the partial match pattern from remote
+func (t *Tiered) GetCacheKeys(ctx context.Context, keyPattern string) ([]string, error) {
+	return t.remote.GetCacheKeys(ctx, keyPattern)
+}
+
+// GetTTL returns the remaining time to live duration for key in remote or 0 if expired
+func (t *Tiered) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	return t.remote.GetTTL(ctx, key)
+}
+
+// Local exposes the local tier, eg to invalidate it
+func (t *Tiered) Local() *xcache.TypedCache[string, []byte] {
+	return t.local
+}
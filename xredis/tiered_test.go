@@ -0,0 +1,249 @@
+package xredis_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/thisisdevelopment/go-dockly/v3/xcache"
+	"github.com/thisisdevelopment/go-dockly/v3/xredis"
+)
+
+// memRedis is an in-memory stand-in for xredis.Redis with the same
+// marshalling and TTL semantics
+type memRedis struct {
+	mu    sync.Mutex
+	items map[string]memItem
+	gets  int
+}
+
+type memItem struct {
+	val []byte
+	exp time.Time
+}
+
+var errNil = errors.New("redis: nil")
+
+func newMemRedis() *memRedis {
+	return &memRedis{items: make(map[string]memItem)}
+}
+
+func (m *memRedis) Set(ctx context.Context, key string, value interface{}, t time.Duration) error {
+	b, ok := value.([]byte)
+	if !ok {
+		var err error
+		if b, err = json.Marshal(value); err != nil {
+			return err
+		}
+	}
+	var exp time.Time
+	if t > 0 {
+		exp = time.Now().Add(t)
+	}
+	m.mu.Lock()
+	m.items[key] = memItem{val: b, exp: exp}
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memRedis) lookup(key string) (memItem, bool) {
+	item, found := m.items[key]
+	if !found || (!item.exp.IsZero() && time.Now().After(item.exp)) {
+		return memItem{}, false
+	}
+	return item, true
+}
+
+func (m *memRedis) Get(ctx context.Context, key string, expected interface{}) error {
+	m.mu.Lock()
+	m.gets++
+	item, found := m.lookup(key)
+	m.mu.Unlock()
+	if !found {
+		return errNil
+	}
+	if _, ok := expected.(*[]byte); ok {
+		reflect.ValueOf(expected).Elem().Set(reflect.ValueOf(item.val))
+		return nil
+	}
+	return json.Unmarshal(item.val, expected)
+}
+
+func (m *memRedis) GetCacheKeys(ctx context.Context, keyPattern string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for k := range m.items {
+		if ok, _ := path.Match(keyPattern, k); ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// GetTTL has the second precision of the redis TTL command
+func (m *memRedis) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, found := m.lookup(key)
+	if !found || item.exp.IsZero() {
+		return 0, nil
+	}
+	return time.Until(item.exp).Truncate(time.Second), nil
+}
+
+func (m *memRedis) GetPTTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, found := m.lookup(key)
+	if !found {
+		return 0, false, nil
+	}
+	if item.exp.IsZero() {
+		return xredis.NoExpiration, true, nil
+	}
+	return time.Until(item.exp).Truncate(time.Millisecond), true, nil
+}
+
+// ttlRedis hides GetPTTL of the remote it wraps
+type ttlRedis struct {
+	xredis.ICache
+}
+
+type tieredValue struct {
+	Name string `json:"name"`
+}
+
+func (s *TestSuite) TestTieredWriteThrough() {
+	ctx := context.Background()
+	remote := newMemRedis()
+	tiered := xredis.NewTiered(xcache.NewCache[string, []byte](time.Minute, 0), remote)
+
+	s.Require().NoError(tiered.Set(ctx, "a", tieredValue{Name: "a"}, time.Minute))
+
+	var v tieredValue
+	s.Require().NoError(tiered.Get(ctx, "a", &v))
+	s.Equal("a", v.Name)
+	s.Equal(0, remote.gets, "expected the read to be served locally")
+
+	var remoteValue tieredValue
+	s.Require().NoError(remote.Get(ctx, "a", &remoteValue))
+	s.Equal("a", remoteValue.Name, "expected the write to go through to remote")
+
+	var raw []byte
+	s.Require().NoError(tiered.Get(ctx, "a", &raw))
+	s.JSONEq(`{"name":"a"}`, string(raw))
+}
+
+func (s *TestSuite) TestTieredBackFill() {
+	ctx := context.Background()
+	remote := newMemRedis()
+	local := xcache.NewCache[string, []byte](time.Hour, 0)
+	tiered := xredis.NewTiered(local, remote)
+
+	s.Require().NoError(remote.Set(ctx, "a", tieredValue{Name: "a"}, 30*time.Second))
+
+	var v tieredValue
+	s.Require().NoError(tiered.Get(ctx, "a", &v))
+	s.Equal("a", v.Name)
+	s.Equal(1, remote.gets)
+
+	// local is back-filled with the remaining remote ttl instead of its default
+	_, exp, found := local.GetWithExpiration("a")
+	s.Require().True(found)
+	s.WithinDuration(time.Now().Add(30*time.Second), exp, time.Second)
+
+	s.Require().NoError(tiered.Get(ctx, "a", &v))
+	s.Equal(1, remote.gets, "expected the second read to be served locally")
+
+	s.Error(tiered.Get(ctx, "missing", &v))
+}
+
+func (s *TestSuite) TestTieredBackFillPrecise() {
+	ctx := context.Background()
+	remote := newMemRedis()
+	local := xcache.NewCache[string, []byte](time.Hour, 0)
+	tiered := xredis.NewTiered(local, remote)
+
+	// in its last second the second precision ttl is already 0
+	s.Require().NoError(remote.Set(ctx, "expiring", 1, 500*time.Millisecond))
+	s.Require().NoError(remote.Set(ctx, "persistent", 2, 0))
+
+	var v int
+	s.Require().NoError(tiered.Get(ctx, "expiring", &v))
+	_, exp, found := local.GetWithExpiration("expiring")
+	s.Require().True(found)
+	s.WithinDuration(time.Now().Add(500*time.Millisecond), exp, 100*time.Millisecond)
+
+	s.Require().NoError(tiered.Get(ctx, "persistent", &v))
+	_, exp, found = local.GetWithExpiration("persistent")
+	s.Require().True(found)
+	s.True(exp.IsZero(), "expected a key without expiry to never expire locally")
+}
+
+func (s *TestSuite) TestTieredBackFillImprecise() {
+	ctx := context.Background()
+	remote := newMemRedis()
+	local := xcache.NewCache[string, []byte](time.Hour, 0)
+	tiered := xredis.NewTiered(local, ttlRedis{remote})
+
+	s.Require().NoError(remote.Set(ctx, "expiring", 1, 500*time.Millisecond))
+	s.Require().NoError(remote.Set(ctx, "persistent", 2, 0))
+	s.Require().NoError(remote.Set(ctx, "a", 3, 30*time.Second))
+
+	// a ttl of 0 may as well mean expired, so neither key is kept locally
+	var v int
+	for _, key := range []string{"expiring", "persistent", "a"} {
+		s.Require().NoError(tiered.Get(ctx, key, &v))
+	}
+	s.Equal([]string{"a"}, keys(local.Items()))
+}
+
+func (s *TestSuite) TestTieredCopiesBytes() {
+	ctx := context.Background()
+	tiered := xredis.NewTiered(xcache.NewCache[string, []byte](time.Minute, 0), newMemRedis())
+
+	payload := []byte("abc")
+	s.Require().NoError(tiered.Set(ctx, "a", payload, time.Minute))
+	payload[0] = 'x'
+
+	var raw []byte
+	s.Require().NoError(tiered.Get(ctx, "a", &raw))
+	s.Equal("abc", string(raw), "expected Set to copy the slice of the caller")
+	raw[0] = 'y'
+
+	s.Require().NoError(tiered.Get(ctx, "a", &raw))
+	s.Equal("abc", string(raw), "expected Get to hand out a copy")
+}
+
+func keys[V any](items map[string]xcache.TypedItem[V]) []string {
+	var ks []string
+	for k := range items {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+func (s *TestSuite) TestTieredDelegates() {
+	ctx := context.Background()
+	remote := newMemRedis()
+	tiered := xredis.NewTiered(xcache.NewCache[string, []byte](time.Minute, 0), remote)
+
+	s.Require().NoError(tiered.Set(ctx, "user:1", 1, time.Minute))
+	s.Require().NoError(tiered.Set(ctx, "user:2", 2, 0))
+
+	keys, err := tiered.GetCacheKeys(ctx, "user:*")
+	s.Require().NoError(err)
+	s.Equal([]string{"user:1", "user:2"}, keys)
+
+	ttl, err := tiered.GetTTL(ctx, "user:1")
+	s.Require().NoError(err)
+	s.True(ttl > 0 && ttl <= time.Minute)
+}